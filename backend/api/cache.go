@@ -0,0 +1,291 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// cacheLoadTimeout bounds a load that runs detached from the callers waiting for it.
+	cacheLoadTimeout = 20 * time.Second
+	// snapshotMaxAge is how old a snapshot entry may be and still be shown while it is
+	// revalidated; older ones are dropped instead of being presented as current data.
+	snapshotMaxAge = 24 * time.Hour
+)
+
+// CachePolicy controls how long a cached response is served.
+// Within TTL the entry is fresh; within TTL+StaleTTL it is served while being revalidated in the background.
+// Persist allows the entry into the on-disk snapshot; leave it off for responses that
+// carry tokens or personal data.
+type CachePolicy struct {
+	TTL      time.Duration
+	StaleTTL time.Duration
+	Persist  bool
+}
+
+type cacheEntry struct {
+	Payload    json.RawMessage `json:"payload"`
+	StoredAt   time.Time       `json:"stored_at"`
+	Persistent bool            `json:"persistent"`
+
+	fromSnapshot bool
+}
+
+type cacheCall struct {
+	done       chan struct{}
+	generation uint64
+	payload    json.RawMessage
+	err        error
+}
+
+type cacheBypassKey struct{}
+
+// ResponseCache is an in-memory cache for Center API reads with in-flight request
+// coalescing, stale-while-revalidate and an optional on-disk snapshot.
+type ResponseCache struct {
+	mu       sync.Mutex
+	entries  map[string]*cacheEntry
+	inflight map[string]*cacheCall
+	// generation is bumped by Invalidate; loads started under an older generation
+	// are not stored, e.g. ones still running for the previous account.
+	generation uint64
+
+	snapshotPath string
+	snapshotMu   sync.Mutex
+}
+
+// NewResponseCache creates a cache. When snapshotPath is not empty, previously saved
+// entries are loaded from it and every update is persisted back.
+func NewResponseCache(snapshotPath string) *ResponseCache {
+	cache := &ResponseCache{
+		entries:      map[string]*cacheEntry{},
+		inflight:     map[string]*cacheCall{},
+		snapshotPath: strings.TrimSpace(snapshotPath),
+	}
+	cache.loadSnapshot()
+	return cache
+}
+
+// BypassCache returns a context that forces a network fetch; the result still updates the cache.
+func BypassCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func isCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+func cacheKey(path string, query map[string]string) string {
+	if len(query) == 0 {
+		return path
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+query[key])
+	}
+	return path + "?" + strings.Join(parts, "&")
+}
+
+// Fetch decodes the cached response for key into dest, calling load when the entry is
+// missing or expired. Stale entries are returned immediately and refreshed in the background.
+func (c *ResponseCache) Fetch(
+	ctx context.Context,
+	key string,
+	policy CachePolicy,
+	dest any,
+	load func(ctx context.Context) (any, error),
+) error {
+	if !isCacheBypassed(ctx) {
+		c.mu.Lock()
+		entry := c.entries[key]
+		if entry != nil && entry.fromSnapshot && time.Since(entry.StoredAt) >= snapshotMaxAge {
+			delete(c.entries, key)
+			entry = nil
+		}
+		if entry != nil {
+			age := time.Since(entry.StoredAt)
+			if age < policy.TTL && !entry.fromSnapshot {
+				c.mu.Unlock()
+				return json.Unmarshal(entry.Payload, dest)
+			}
+			if age < policy.TTL+policy.StaleTTL || entry.fromSnapshot {
+				c.mu.Unlock()
+				c.revalidate(ctx, key, policy, load)
+				return json.Unmarshal(entry.Payload, dest)
+			}
+		}
+		c.mu.Unlock()
+	}
+
+	payload, err := c.do(ctx, key, policy, load)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, dest)
+}
+
+// Invalidate removes every entry whose key starts with one of the prefixes.
+// Without prefixes the whole cache is cleared. Loads already in flight finish for
+// their callers but are no longer stored or joined by new callers.
+func (c *ResponseCache) Invalidate(prefixes ...string) {
+	c.mu.Lock()
+	c.generation++
+	for key := range c.entries {
+		if len(prefixes) == 0 || hasAnyPrefix(key, prefixes) {
+			delete(c.entries, key)
+		}
+	}
+	for key := range c.inflight {
+		if len(prefixes) == 0 || hasAnyPrefix(key, prefixes) {
+			delete(c.inflight, key)
+		}
+	}
+	c.mu.Unlock()
+	c.saveSnapshot()
+}
+
+func (c *ResponseCache) revalidate(ctx context.Context, key string, policy CachePolicy, load func(ctx context.Context) (any, error)) {
+	c.mu.Lock()
+	_, running := c.inflight[key]
+	c.mu.Unlock()
+	if running {
+		return
+	}
+
+	go func() {
+		_, _ = c.do(context.WithoutCancel(ctx), key, policy, load)
+	}()
+}
+
+// do runs load once per key at a time; concurrent callers wait for the same result.
+// The load runs detached from the callers' contexts, so cancelling one caller does not
+// fail the others.
+func (c *ResponseCache) do(ctx context.Context, key string, policy CachePolicy, load func(ctx context.Context) (any, error)) (json.RawMessage, error) {
+	c.mu.Lock()
+	call, ok := c.inflight[key]
+	if !ok {
+		call = &cacheCall{done: make(chan struct{}), generation: c.generation}
+		c.inflight[key] = call
+		go c.runLoad(context.WithoutCancel(ctx), key, policy, call, load)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.payload, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *ResponseCache) runLoad(ctx context.Context, key string, policy CachePolicy, call *cacheCall, load func(ctx context.Context) (any, error)) {
+	loadCtx, cancel := context.WithTimeout(ctx, cacheLoadTimeout)
+	defer cancel()
+
+	value, err := load(loadCtx)
+	if err == nil {
+		call.payload, err = json.Marshal(value)
+		if err != nil {
+			err = fmt.Errorf("encode cached response for %s: %w", key, err)
+		}
+	}
+	call.err = err
+
+	c.mu.Lock()
+	if c.inflight[key] == call {
+		delete(c.inflight, key)
+	}
+	stored := err == nil && call.generation == c.generation
+	if stored {
+		c.entries[key] = &cacheEntry{Payload: call.payload, StoredAt: time.Now().UTC(), Persistent: policy.Persist}
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	if stored && policy.Persist {
+		c.saveSnapshot()
+	}
+}
+
+func (c *ResponseCache) loadSnapshot() {
+	if c.snapshotPath == "" {
+		return
+	}
+
+	raw, err := os.ReadFile(c.snapshotPath)
+	if err != nil {
+		return
+	}
+	var entries map[string]*cacheEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return
+	}
+	dropped := false
+	for key, entry := range entries {
+		// Snapshots written before Persist existed may hold entries such as /user/info.
+		if entry == nil || len(entry.Payload) == 0 || !entry.Persistent || time.Since(entry.StoredAt) >= snapshotMaxAge {
+			dropped = true
+			continue
+		}
+		entry.fromSnapshot = true
+		c.entries[key] = entry
+	}
+	if dropped {
+		c.saveSnapshot()
+	}
+}
+
+func (c *ResponseCache) saveSnapshot() {
+	if c.snapshotPath == "" {
+		return
+	}
+
+	c.mu.Lock()
+	persistent := make(map[string]*cacheEntry, len(c.entries))
+	for key, entry := range c.entries {
+		if entry.Persistent {
+			persistent[key] = entry
+		}
+	}
+	payload, err := json.Marshal(persistent)
+	c.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	c.snapshotMu.Lock()
+	defer c.snapshotMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(c.snapshotPath), 0o755); err != nil {
+		return
+	}
+	tempPath := c.snapshotPath + ".tmp"
+	if err := os.WriteFile(tempPath, payload, 0o600); err != nil {
+		return
+	}
+	if err := os.Rename(tempPath, c.snapshotPath); err != nil {
+		_ = os.Remove(tempPath)
+	}
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,24 +2,85 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	neturl "net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"loliashizuku/backend/httpclient"
 	"loliashizuku/backend/models"
 )
 
+const (
+	listAllTunnelsPageSize    = 100
+	listAllTunnelsConcurrency = 4
+)
+
+var (
+	userInfoCachePolicy      = CachePolicy{TTL: 30 * time.Second, StaleTTL: 5 * time.Minute}
+	nodesCachePolicy         = CachePolicy{TTL: time.Minute, StaleTTL: 10 * time.Minute, Persist: true}
+	clientVersionCachePolicy = CachePolicy{TTL: 10 * time.Minute, StaleTTL: time.Hour, Persist: true}
+	homeStatsCachePolicy     = CachePolicy{TTL: 5 * time.Minute, StaleTTL: time.Hour, Persist: true}
+)
+
 type CenterAPI struct {
 	client *httpclient.Client
+	cache  *ResponseCache
+}
+
+// NewCenterAPI creates a CenterAPI. A nil cache disables response caching.
+func NewCenterAPI(client *httpclient.Client, cache *ResponseCache) *CenterAPI {
+	return &CenterAPI{client: client, cache: cache}
 }
 
-func NewCenterAPI(client *httpclient.Client) *CenterAPI {
-	return &CenterAPI{client: client}
+// InvalidateCache drops cached responses whose key starts with one of the paths,
+// or every cached response when no path is given.
+func (a *CenterAPI) InvalidateCache(paths ...string) {
+	if a.cache == nil {
+		return
+	}
+	a.cache.Invalidate(paths...)
+}
+
+// doCachedJSON behaves like DoJSON but serves the response through the cache when one is configured.
+func (a *CenterAPI) doCachedJSON(
+	ctx context.Context,
+	policy CachePolicy,
+	method, path string,
+	query map[string]string,
+	body any,
+	dest any,
+) error {
+	if a.cache == nil {
+		return a.client.DoJSON(ctx, method, path, query, body, dest)
+	}
+
+	return a.cache.Fetch(ctx, cacheKey(path, query), policy, dest, func(ctx context.Context) (any, error) {
+		var raw json.RawMessage
+		if err := a.client.DoJSON(ctx, method, path, query, body, &raw); err != nil {
+			return nil, err
+		}
+		if len(raw) == 0 {
+			raw = json.RawMessage("null")
+		}
+		return raw, nil
+	})
 }
 
 func (a *CenterAPI) GetUserInfo(ctx context.Context) (*models.UserInfoData, error) {
+	var data models.UserInfoData
+	if err := a.doCachedJSON(ctx, userInfoCachePolicy, http.MethodGet, "/user/info", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetUserInfoUncached fetches /user/info without reading or filling the cache, for
+// lookups made with a token other than the active account's.
+func (a *CenterAPI) GetUserInfoUncached(ctx context.Context) (*models.UserInfoData, error) {
 	var data models.UserInfoData
 	if err := a.client.DoJSON(ctx, http.MethodGet, "/user/info", nil, nil, &data); err != nil {
 		return nil, err
@@ -47,6 +108,81 @@ func (a *CenterAPI) GetUserTunnels(ctx context.Context, page, limit int) (*model
 	return &data, nil
 }
 
+// ListAllTunnels follows TotalPage and returns every tunnel of the account in page order.
+// Pages after the first are fetched concurrently, at most listAllTunnelsConcurrency at a time.
+func (a *CenterAPI) ListAllTunnels(ctx context.Context) (*models.TunnelListData, error) {
+	first, err := a.GetUserTunnels(ctx, 1, listAllTunnelsPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPage := int(first.TotalPage)
+	if totalPage <= 1 {
+		return first, nil
+	}
+
+	pages := make([][]models.TunnelItem, totalPage)
+	pages[0] = first.List
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, listAllTunnelsConcurrency)
+	for page := 2; page <= totalPage; page++ {
+		wg.Add(1)
+		go func(page int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			data, pageErr := a.GetUserTunnels(ctx, page, listAllTunnelsPageSize)
+			if pageErr != nil {
+				errOnce.Do(func() {
+					firstErr = pageErr
+					cancel()
+				})
+				return
+			}
+			pages[page-1] = data.List
+		}(page)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// Total is only a capacity hint; a negative value from the server would panic make.
+	capacity := min(max(int(first.Total), 0), totalPage*listAllTunnelsPageSize)
+	seen := make(map[int64]struct{}, capacity)
+	all := make([]models.TunnelItem, 0, capacity)
+	for _, list := range pages {
+		for _, item := range list {
+			if _, ok := seen[item.ID]; ok {
+				continue
+			}
+			seen[item.ID] = struct{}{}
+			all = append(all, item)
+		}
+	}
+
+	return &models.TunnelListData{
+		Limit:     int64(len(all)),
+		List:      all,
+		Page:      1,
+		Total:     int64(len(all)),
+		TotalPage: 1,
+	}, nil
+}
+
 func (a *CenterAPI) GetTrafficTunnels(ctx context.Context, days int) (*models.TrafficTunnelData, error) {
 	query := map[string]string{
 		"days": strconv.Itoa(days),
@@ -71,7 +207,7 @@ func (a *CenterAPI) GetTrafficDaily(ctx context.Context, days int) (*models.Dail
 
 func (a *CenterAPI) GetNodes(ctx context.Context) (*models.NodeListData, error) {
 	var data models.NodeListData
-	if err := a.client.DoJSON(ctx, http.MethodPost, "/user/nodes", nil, map[string]any{}, &data); err != nil {
+	if err := a.doCachedJSON(ctx, nodesCachePolicy, http.MethodPost, "/user/nodes", nil, map[string]any{}, &data); err != nil {
 		return nil, err
 	}
 	return &data, nil
@@ -102,9 +238,38 @@ func (a *CenterAPI) GetTunnelDetail(ctx context.Context, tunnelName string) (*mo
 	return &data, nil
 }
 
+// CreateTunnel creates a tunnel from spec.
+func (a *CenterAPI) CreateTunnel(ctx context.Context, spec models.TunnelSpec) error {
+	if err := a.client.DoJSON(ctx, http.MethodPost, "/user/tunnel", nil, spec, nil); err != nil {
+		return err
+	}
+	a.InvalidateCache("/user/info", "/user/tunnel")
+	return nil
+}
+
+// UpdateTunnel replaces the settings of the tunnel called name with spec.
+func (a *CenterAPI) UpdateTunnel(ctx context.Context, name string, spec models.TunnelSpec) error {
+	path := "/user/tunnel/" + neturl.PathEscape(strings.TrimSpace(name))
+	if err := a.client.DoJSON(ctx, http.MethodPut, path, nil, spec, nil); err != nil {
+		return err
+	}
+	a.InvalidateCache("/user/info", "/user/tunnel")
+	return nil
+}
+
+// DeleteTunnel deletes the tunnel called name.
+func (a *CenterAPI) DeleteTunnel(ctx context.Context, name string) error {
+	path := "/user/tunnel/" + neturl.PathEscape(strings.TrimSpace(name))
+	if err := a.client.DoJSON(ctx, http.MethodDelete, path, nil, nil, nil); err != nil {
+		return err
+	}
+	a.InvalidateCache("/user/info", "/user/tunnel")
+	return nil
+}
+
 func (a *CenterAPI) GetClientVersion(ctx context.Context) (*models.AppVersionInfo, error) {
 	var data models.AppVersionInfo
-	if err := a.client.DoJSON(ctx, http.MethodGet, "/client/version", nil, nil, &data); err != nil {
+	if err := a.doCachedJSON(ctx, clientVersionCachePolicy, http.MethodGet, "/client/version", nil, nil, &data); err != nil {
 		return nil, err
 	}
 	return &data, nil
@@ -112,8 +277,19 @@ func (a *CenterAPI) GetClientVersion(ctx context.Context) (*models.AppVersionInf
 
 func (a *CenterAPI) GetHomeStats(ctx context.Context) (*models.HomeStatsData, error) {
 	var data models.HomeStatsData
-	if err := a.client.DoJSON(ctx, http.MethodGet, "/home", nil, nil, &data); err != nil {
+	if err := a.doCachedJSON(ctx, homeStatsCachePolicy, http.MethodGet, "/home", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// CheckIn performs the daily check-in and drops the cached user info so the new
+// traffic limit and TodayChecked flag are fetched on the next read.
+func (a *CenterAPI) CheckIn(ctx context.Context) (*models.CheckInResult, error) {
+	var data models.CheckInResult
+	if err := a.client.DoJSON(ctx, http.MethodPost, "/user/checkin", nil, map[string]any{}, &data); err != nil {
 		return nil, err
 	}
+	a.InvalidateCache("/user/info", "/user/traffic")
 	return &data, nil
 }
@@ -3,23 +3,52 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"loliashizuku/backend/httpclient"
 	"loliashizuku/backend/models"
 )
 
+const defaultGitHubAPIBaseURL = "https://api.github.com"
+
 type GitHubReleaseAPI struct {
 	httpClient *http.Client
-	userAgent  string
+	baseURL    string
+
+	rateLimitMu sync.Mutex
+	rateLimit   *models.GitHubRateLimit
 }
 
-func NewGitHubReleaseAPI(httpClient *http.Client, userAgent string) *GitHubReleaseAPI {
+// ErrGitHubTokenRejected is returned by CheckToken when GitHub does not accept a token.
+var ErrGitHubTokenRejected = errors.New("github token rejected")
+
+// NewGitHubReleaseAPI creates a GitHubReleaseAPI. Requests run through middlewares,
+// outermost first, and then through the GitHub headers.
+func NewGitHubReleaseAPI(httpClient *http.Client, userAgent string, middlewares ...httpclient.Middleware) *GitHubReleaseAPI {
+	headers := httpclient.Headers(map[string]string{
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+		"User-Agent":           strings.TrimSpace(userAgent),
+	})
 	return &GitHubReleaseAPI{
-		httpClient: httpClient,
-		userAgent:  strings.TrimSpace(userAgent),
+		httpClient: httpclient.WrapClient(httpClient, append(middlewares, headers)...),
+		baseURL:    defaultGitHubAPIBaseURL,
+	}
+}
+
+// SetBaseURL points the API at a GitHub-compatible server. An empty value restores the default.
+func (a *GitHubReleaseAPI) SetBaseURL(baseURL string) {
+	trimmed := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if trimmed == "" {
+		trimmed = defaultGitHubAPIBaseURL
 	}
+	a.baseURL = trimmed
 }
 
 func (a *GitHubReleaseAPI) GetLatestRelease(ctx context.Context, owner, repo string) (*models.GitHubRelease, error) {
@@ -30,7 +59,8 @@ func (a *GitHubReleaseAPI) GetLatestRelease(ctx context.Context, owner, repo str
 	}
 
 	url := fmt.Sprintf(
-		"https://api.github.com/repos/%s/%s/releases/latest",
+		"%s/repos/%s/%s/releases/latest",
+		a.baseURL,
 		trimmedOwner,
 		trimmedRepo,
 	)
@@ -40,19 +70,17 @@ func (a *GitHubReleaseAPI) GetLatestRelease(ctx context.Context, owner, repo str
 		return nil, fmt.Errorf("build github release request: %w", err)
 	}
 
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
-	if a.userAgent != "" {
-		req.Header.Set("User-Agent", a.userAgent)
-	}
-
 	resp, err := a.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request github latest release: %w", err)
 	}
 	defer resp.Body.Close()
+	limit := a.recordRateLimit(resp.Header)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if limit != nil && limit.Remaining == 0 && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) {
+			return nil, fmt.Errorf("github api rate limit exceeded, resets at %s", limit.ResetAt)
+		}
 		return nil, fmt.Errorf("github latest release failed: status=%d", resp.StatusCode)
 	}
 
@@ -62,3 +90,62 @@ func (a *GitHubReleaseAPI) GetLatestRelease(ctx context.Context, owner, repo str
 	}
 	return &release, nil
 }
+
+// RateLimit returns the quota reported by the last GitHub response, or nil before the first one.
+func (a *GitHubReleaseAPI) RateLimit() *models.GitHubRateLimit {
+	a.rateLimitMu.Lock()
+	defer a.rateLimitMu.Unlock()
+	if a.rateLimit == nil {
+		return nil
+	}
+	limit := *a.rateLimit
+	return &limit
+}
+
+// CheckToken verifies token with the rate limit endpoint, which does not use quota.
+func (a *GitHubReleaseAPI) CheckToken(ctx context.Context, token string) (*models.GitHubRateLimit, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+"/rate_limit", nil)
+	if err != nil {
+		return nil, fmt.Errorf("build github rate limit request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(token))
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request github rate limit: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrGitHubTokenRejected
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("github rate limit failed: status=%d", resp.StatusCode)
+	}
+	return a.recordRateLimit(resp.Header), nil
+}
+
+// recordRateLimit parses the X-RateLimit-* headers and remembers them. It returns nil
+// when the response carries none, e.g. from a mirror that strips them.
+func (a *GitHubReleaseAPI) recordRateLimit(header http.Header) *models.GitHubRateLimit {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return nil
+	}
+	limit := &models.GitHubRateLimit{
+		Remaining: remaining,
+		Resource:  header.Get("X-RateLimit-Resource"),
+		CheckedAt: time.Now().Format(time.RFC3339),
+		FromCache: header.Get("X-From-Cache") != "",
+	}
+	limit.Limit, _ = strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	limit.Used, _ = strconv.Atoi(header.Get("X-RateLimit-Used"))
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		limit.ResetAt = time.Unix(reset, 0).Format(time.RFC3339)
+	}
+
+	a.rateLimitMu.Lock()
+	a.rateLimit = limit
+	a.rateLimitMu.Unlock()
+	return limit
+}
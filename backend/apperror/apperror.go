@@ -0,0 +1,288 @@
+// Package apperror defines the typed errors returned to the frontend. Every error
+// carries a stable Code the UI can branch on, and its message is looked up in a
+// zh-CN/en catalog.
+package apperror
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"loliashizuku/backend/httpclient"
+	"loliashizuku/backend/models"
+	"loliashizuku/backend/tlstrust"
+)
+
+type Code string
+
+const (
+	CodeUnknown             Code = "unknown"
+	CodeUnauthorized        Code = "unauthorized"
+	CodeForbidden           Code = "forbidden"
+	CodeNotFound            Code = "not_found"
+	CodeInvalidArgument     Code = "invalid_argument"
+	CodeNetwork             Code = "network"
+	CodeTimeout             Code = "timeout"
+	CodeCancelled           Code = "cancelled"
+	CodeRateLimited         Code = "rate_limited"
+	CodeServer              Code = "server_error"
+	CodeAPI                 Code = "api_error"
+	CodeNotInstalled        Code = "not_installed"
+	CodeRunnerBusy          Code = "runner_busy"
+	CodeInstallInProgress   Code = "install_in_progress"
+	CodeInstallCancelled    Code = "install_cancelled"
+	CodeInstallTimeout      Code = "install_timeout"
+	CodeChecksumMismatch    Code = "checksum_mismatch"
+	CodeUnsupportedPlatform Code = "unsupported_platform"
+	CodeNoTunnel            Code = "no_tunnel"
+	CodeInvalidTunnel       Code = "invalid_tunnel"
+	CodeStorage             Code = "storage"
+	CodeAccountNotFound     Code = "account_not_found"
+	CodeAccountSignedOut    Code = "account_signed_out"
+	CodeCertificate         Code = "certificate"
+	CodePortInUse           Code = "port_in_use"
+	CodePlanChanged         Code = "plan_changed"
+	CodeRunnerStart         Code = "runner_start_failed"
+	CodeInstallFailed       Code = "install_failed"
+	CodeNoNode              Code = "no_node"
+	CodeDialog              Code = "dialog_failed"
+)
+
+const (
+	LocaleZhCN = "zh-CN"
+	LocaleEn   = "en"
+)
+
+// DefaultLocale is the locale of Error() and Payload.Message. The UI has no language
+// setting; Payload.Messages carries every locale for the frontend to choose from.
+const DefaultLocale = LocaleZhCN
+
+// Error is a typed application error.
+type Error struct {
+	Code Code
+	// Params fill the {name} placeholders of the catalog message.
+	Params map[string]string
+	// Detail is extra, non-localized diagnostic text such as the upstream message.
+	Detail string
+	// Status and BusinessCode are copied from the Center API response when available.
+	Status       int
+	BusinessCode int
+	// Certificates is the chain a server presented when its certificate was rejected.
+	Certificates []models.CertificateInfo
+
+	cause error
+}
+
+// New creates an error with the given code. params are key/value pairs for the message.
+func New(code Code, params ...string) *Error {
+	e := &Error{Code: code}
+	for i := 0; i+1 < len(params); i += 2 {
+		e = e.WithParam(params[i], params[i+1])
+	}
+	return e
+}
+
+// Wrap creates an error with the given code that wraps cause; cause's text becomes the detail.
+func Wrap(code Code, cause error, params ...string) *Error {
+	e := New(code, params...)
+	e.cause = cause
+	if cause != nil {
+		e.Detail = cause.Error()
+	}
+	return e
+}
+
+// WithParam sets a message parameter and returns e.
+func (e *Error) WithParam(key, value string) *Error {
+	if e.Params == nil {
+		e.Params = map[string]string{}
+	}
+	e.Params[key] = value
+	return e
+}
+
+// WithDetail sets the diagnostic detail and returns e.
+func (e *Error) WithDetail(detail string) *Error {
+	e.Detail = strings.TrimSpace(detail)
+	return e
+}
+
+func (e *Error) Error() string {
+	return e.Message(DefaultLocale)
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches another *Error with the same code, so errors.Is(err, apperror.New(code)) works.
+func (e *Error) Is(target error) bool {
+	var other *Error
+	if !errors.As(target, &other) {
+		return false
+	}
+	return other.Code == e.Code
+}
+
+// Message returns the localized message for locale.
+func (e *Error) Message(locale string) string {
+	if e.Code == CodeUnknown && e.Detail != "" {
+		// Unclassified errors keep their original text.
+		return e.Detail
+	}
+	return render(lookup(e.Code, locale), e.Params, e.Detail)
+}
+
+// HasCode reports whether err is an *Error with the given code.
+func HasCode(err error, code Code) bool {
+	var appErr *Error
+	return errors.As(err, &appErr) && appErr.Code == code
+}
+
+// From converts any error to an *Error, classifying well-known failures.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var apiErr *httpclient.APIError
+	if errors.As(err, &apiErr) {
+		mapped := FromAPIError(apiErr)
+		if errors.Is(err, httpclient.ErrUnauthorized) && mapped.Code != CodeForbidden {
+			mapped.Code = CodeUnauthorized
+		}
+		mapped.cause = err
+		return mapped
+	}
+
+	switch {
+	case errors.Is(err, httpclient.ErrUnauthorized):
+		return Wrap(CodeUnauthorized, err)
+	case errors.Is(err, context.Canceled):
+		return Wrap(CodeCancelled, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(CodeTimeout, err)
+	}
+
+	if chain, host, ok := tlstrust.ChainFromError(err); ok {
+		mapped := Wrap(CodeCertificate, err, "host", host)
+		mapped.Certificates = chain
+		return mapped
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return Wrap(CodeTimeout, err)
+		}
+		return Wrap(CodeNetwork, err)
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return Wrap(CodeNetwork, err)
+	}
+
+	return Wrap(CodeUnknown, err)
+}
+
+// FromAPIError maps a Center API failure to a code using the business code first,
+// then the HTTP status.
+func FromAPIError(apiErr *httpclient.APIError) *Error {
+	code := codeForStatus(apiErr.Code)
+	if code == CodeAPI {
+		code = codeForStatus(apiErr.StatusCode)
+	}
+
+	e := New(code).WithDetail(apiErr.Message)
+	e.Status = apiErr.StatusCode
+	e.BusinessCode = apiErr.Code
+	e.cause = apiErr
+	return e
+}
+
+func codeForStatus(status int) Code {
+	switch {
+	case status == http.StatusUnauthorized:
+		return CodeUnauthorized
+	case status == http.StatusForbidden:
+		return CodeForbidden
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusTooManyRequests:
+		return CodeRateLimited
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return CodeInvalidArgument
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return CodeTimeout
+	case status >= 500 && status <= 599:
+		return CodeServer
+	default:
+		return CodeAPI
+	}
+}
+
+// Payload is the JSON shape of an error delivered to the frontend.
+type Payload struct {
+	Code         Code                     `json:"code"`
+	Message      string                   `json:"message"`
+	Messages     map[string]string        `json:"messages"`
+	Detail       string                   `json:"detail,omitempty"`
+	Params       map[string]string        `json:"params,omitempty"`
+	Status       int                      `json:"status,omitempty"`
+	BusinessCode int                      `json:"business_code,omitempty"`
+	Certificates []models.CertificateInfo `json:"certificates,omitempty"`
+}
+
+// ToPayload converts err to its frontend representation.
+func ToPayload(err error) Payload {
+	appErr := From(err)
+	if appErr == nil {
+		appErr = New(CodeUnknown)
+	}
+
+	messages := make(map[string]string, len(catalog[CodeUnknown]))
+	for _, locale := range Locales() {
+		messages[locale] = appErr.Message(locale)
+	}
+	return Payload{
+		Code:         appErr.Code,
+		Message:      appErr.Message(DefaultLocale),
+		Messages:     messages,
+		Detail:       appErr.Detail,
+		Params:       appErr.Params,
+		Status:       appErr.Status,
+		BusinessCode: appErr.BusinessCode,
+		Certificates: appErr.Certificates,
+	}
+}
+
+// Format is a Wails ErrorFormatter that returns errors as Payload objects.
+func Format(err error) any {
+	return ToPayload(err)
+}
+
+// Locales returns the locales available in the catalog.
+func Locales() []string {
+	locales := make([]string, 0, len(catalog[CodeUnknown]))
+	for locale := range catalog[CodeUnknown] {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+func normalizeLocale(locale string) string {
+	normalized := strings.ToLower(strings.TrimSpace(locale))
+	if strings.HasPrefix(normalized, "en") {
+		return LocaleEn
+	}
+	return LocaleZhCN
+}
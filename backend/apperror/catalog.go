@@ -0,0 +1,156 @@
+package apperror
+
+import "strings"
+
+// catalog holds the localized message templates. {name} placeholders are filled from
+// Error.Params; {detail} is filled from Error.Detail.
+var catalog = map[Code]map[string]string{
+	CodeUnknown: {
+		LocaleZhCN: "未知错误",
+		LocaleEn:   "Unknown error",
+	},
+	CodeUnauthorized: {
+		LocaleZhCN: "登录已失效，请重新登录",
+		LocaleEn:   "Your session has expired, please sign in again",
+	},
+	CodeForbidden: {
+		LocaleZhCN: "没有权限执行此操作",
+		LocaleEn:   "You do not have permission to do this",
+	},
+	CodeNotFound: {
+		LocaleZhCN: "请求的资源不存在",
+		LocaleEn:   "The requested resource was not found",
+	},
+	CodeInvalidArgument: {
+		LocaleZhCN: "参数无效：{detail}",
+		LocaleEn:   "Invalid argument: {detail}",
+	},
+	CodeNetwork: {
+		LocaleZhCN: "网络连接失败，请检查网络",
+		LocaleEn:   "Network error, please check your connection",
+	},
+	CodeTimeout: {
+		LocaleZhCN: "请求超时，请稍后重试",
+		LocaleEn:   "The request timed out, please try again later",
+	},
+	CodeCancelled: {
+		LocaleZhCN: "操作已取消",
+		LocaleEn:   "The operation was cancelled",
+	},
+	CodeRateLimited: {
+		LocaleZhCN: "请求过于频繁，请稍后再试",
+		LocaleEn:   "Too many requests, please try again later",
+	},
+	CodeServer: {
+		LocaleZhCN: "服务器错误，请稍后重试",
+		LocaleEn:   "Server error, please try again later",
+	},
+	CodeAPI: {
+		LocaleZhCN: "服务返回错误：{detail}",
+		LocaleEn:   "The server returned an error: {detail}",
+	},
+	CodeNotInstalled: {
+		LocaleZhCN: "frpc 未安装，请先在设置页面安装",
+		LocaleEn:   "frpc is not installed, please install it in Settings first",
+	},
+	CodeRunnerBusy: {
+		LocaleZhCN: "runner 已在运行中",
+		LocaleEn:   "The runner is already running",
+	},
+	CodeInstallInProgress: {
+		LocaleZhCN: "frpc 下载/安装正在进行中",
+		LocaleEn:   "An frpc download or install is already in progress",
+	},
+	CodeInstallCancelled: {
+		LocaleZhCN: "frpc 下载已终止",
+		LocaleEn:   "The frpc download was cancelled",
+	},
+	CodeInstallTimeout: {
+		LocaleZhCN: "frpc 下载超时，请稍后重试",
+		LocaleEn:   "The frpc download timed out, please try again later",
+	},
+	CodeChecksumMismatch: {
+		LocaleZhCN: "文件校验失败：{name}",
+		LocaleEn:   "Checksum mismatch for {name}",
+	},
+	CodeUnsupportedPlatform: {
+		LocaleZhCN: "不支持的平台：{platform}",
+		LocaleEn:   "Unsupported platform: {platform}",
+	},
+	CodeNoTunnel: {
+		LocaleZhCN: "当前账号暂无隧道，无法启动 frpc",
+		LocaleEn:   "This account has no tunnels, frpc cannot be started",
+	},
+	CodeInvalidTunnel: {
+		LocaleZhCN: "隧道信息无效：{tunnel}",
+		LocaleEn:   "Invalid tunnel: {tunnel}",
+	},
+	CodeStorage: {
+		LocaleZhCN: "读写本地数据失败",
+		LocaleEn:   "Failed to read or write local data",
+	},
+	CodeAccountNotFound: {
+		LocaleZhCN: "账号不存在：{account}",
+		LocaleEn:   "Account not found: {account}",
+	},
+	CodeAccountSignedOut: {
+		LocaleZhCN: "账号 {account} 已退出登录，请重新登录",
+		LocaleEn:   "Account {account} is signed out, please sign in again",
+	},
+	CodeCertificate: {
+		LocaleZhCN: "{host} 的证书校验失败：{detail}",
+		LocaleEn:   "Certificate verification failed for {host}: {detail}",
+	},
+	CodePortInUse: {
+		LocaleZhCN: "登录回调端口 {port} 已被占用：{detail}",
+		LocaleEn:   "Login callback port {port} is already in use: {detail}",
+	},
+	CodePlanChanged: {
+		LocaleZhCN: "隧道或导入文件在预览后已变化，请重新预览",
+		LocaleEn:   "The tunnels or the import file changed after the preview, please preview again",
+	},
+	CodeRunnerStart: {
+		LocaleZhCN: "启动 frpc 失败：{detail}",
+		LocaleEn:   "Failed to start frpc: {detail}",
+	},
+	CodeInstallFailed: {
+		LocaleZhCN: "frpc 下载/安装失败：{detail}",
+		LocaleEn:   "Failed to download or install frpc: {detail}",
+	},
+	CodeNoNode: {
+		LocaleZhCN: "没有可用的节点",
+		LocaleEn:   "No node is available",
+	},
+	CodeDialog: {
+		LocaleZhCN: "打开文件对话框失败：{detail}",
+		LocaleEn:   "Failed to open the file dialog: {detail}",
+	},
+}
+
+func lookup(code Code, locale string) string {
+	messages, ok := catalog[code]
+	if !ok {
+		messages = catalog[CodeUnknown]
+	}
+	if message, ok := messages[normalizeLocale(locale)]; ok {
+		return message
+	}
+	return messages[LocaleZhCN]
+}
+
+func render(template string, params map[string]string, detail string) string {
+	if !strings.Contains(template, "{") {
+		return template
+	}
+	pairs := make([]string, 0, len(params)*2+2)
+	for key, value := range params {
+		pairs = append(pairs, "{"+key+"}", value)
+	}
+	pairs = append(pairs, "{detail}", detail)
+	rendered := strings.NewReplacer(pairs...).Replace(template)
+	if detail == "" {
+		// Drop the dangling separator of templates that end with an empty {detail}.
+		rendered = strings.TrimRight(rendered, "：: ")
+	}
+	return rendered
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 // Config 表示应用程序配置
@@ -13,12 +14,15 @@ type Config struct {
 	App      AppConfig      `json:"app"`      // 应用程序相关设置
 	Theme    ThemeConfig    `json:"theme"`    // 主题相关设置
 	Window   WindowConfig   `json:"window"`   // 窗口相关设置
+	Network  NetworkConfig  `json:"network"`  // 网络设置
 	Advanced AdvancedConfig `json:"advanced"` // 高级设置
 }
 
 // AppConfig 包含应用程序特定的设置
 type AppConfig struct {
-	AutoStart bool `json:"autoStart"` // 是否自动启动
+	AutoStart       bool `json:"autoStart"`       // 是否自动启动
+	AutoCheckIn     bool `json:"autoCheckIn"`     // 是否在应用运行时自动每日签到
+	PauseOnNodeDown bool `json:"pauseOnNodeDown"` // 节点离线时暂停隧道，节点恢复后自动重新启动
 }
 
 // ThemeConfig 包含主题设置
@@ -34,6 +38,33 @@ type WindowConfig struct {
 	Maximised bool `json:"maximised"` // 是否最大化
 }
 
+// 代理模式
+const (
+	ProxyModeNone   = "none"   // 直连
+	ProxyModeSystem = "system" // 跟随 HTTP_PROXY、HTTPS_PROXY、NO_PROXY 环境变量
+	ProxyModeManual = "manual" // 使用 ProxyConfig.URL
+)
+
+// NetworkConfig 包含网络设置
+type NetworkConfig struct {
+	Proxy ProxyConfig `json:"proxy"` // 出站代理
+	TLS   TLSConfig   `json:"tls"`   // TLS 信任设置
+}
+
+// ProxyConfig 包含出站代理设置，作用于 Center API、GitHub、frpc 下载和 OAuth 请求
+type ProxyConfig struct {
+	Mode        string `json:"mode"`        // 代理模式：none, system, manual
+	URL         string `json:"url"`         // 手动代理地址，支持 http://、https://、socks5://，可包含用户名和密码
+	NoProxy     string `json:"noProxy"`     // 不走代理的主机，逗号分隔，格式同 NO_PROXY
+	ApplyToFrpc bool   `json:"applyToFrpc"` // 是否让 frpc 连接节点时也使用该代理
+}
+
+// TLSConfig 包含 TLS 信任设置，作用于后端发起的所有 HTTPS 请求
+type TLSConfig struct {
+	CAFiles    []string `json:"caFiles"`    // 在系统证书之外额外信任的 CA 证书 PEM 文件
+	CenterPins []string `json:"centerPins"` // Center API 主机的 SPKI 固定，格式 sha256/<base64>，为空时不固定
+}
+
 // AdvancedConfig 包含高级设置
 type AdvancedConfig struct {
 	LogLevel  string `json:"logLevel"`  // 日志级别
@@ -41,7 +72,10 @@ type AdvancedConfig struct {
 }
 
 // Manager 处理配置操作
+// 配置会被后台 goroutine 与 Wails 调用同时读写，所有访问都经过 mu
 type Manager struct {
+	mu         sync.RWMutex
+	configDir  string
 	configPath string
 	config     *Config
 }
@@ -53,12 +87,22 @@ func NewManager() *Manager {
 	}
 }
 
+// NewManagerAt 创建一个将 config.json 保存在 dir 下的配置管理器，例如演示模式的独立目录
+func NewManagerAt(dir string) *Manager {
+	return &Manager{
+		configDir: dir,
+		config:    getDefaultConfig(),
+	}
+}
+
 // getDefaultConfig 返回默认配置
 func getDefaultConfig() *Config {
 	return &Config{
 		Version: "0.0.1",
 		App: AppConfig{
-			AutoStart: false,
+			AutoStart:       false,
+			AutoCheckIn:     false,
+			PauseOnNodeDown: false,
 		},
 		Theme: ThemeConfig{
 			Mode:        "auto",
@@ -69,6 +113,11 @@ func getDefaultConfig() *Config {
 			Height:    600,
 			Maximised: false,
 		},
+		Network: NetworkConfig{
+			Proxy: ProxyConfig{
+				Mode: ProxyModeSystem,
+			},
+		},
 		Advanced: AdvancedConfig{
 			LogLevel:  "info",
 			DebugMode: false,
@@ -78,29 +127,34 @@ func getDefaultConfig() *Config {
 
 // Initialize 设置配置管理器并加载配置
 func (m *Manager) Initialize() error {
-	// 获取配置目录
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		return fmt.Errorf("无法获取配置目录: %w", err)
+	appConfigDir := m.configDir
+	if appConfigDir == "" {
+		// 获取配置目录
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return fmt.Errorf("无法获取配置目录: %w", err)
+		}
+		appConfigDir = filepath.Join(configDir, "LoliaShizuku")
 	}
 
 	// 创建应用程序特定的配置目录
-	appConfigDir := filepath.Join(configDir, "LoliaShizuku")
 	if err := os.MkdirAll(appConfigDir, 0755); err != nil {
 		return fmt.Errorf("无法创建配置目录: %w", err)
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.configPath = filepath.Join(appConfigDir, "config.json")
 
 	// 如果配置文件存在则加载，否则创建默认配置
 	if _, err := os.Stat(m.configPath); os.IsNotExist(err) {
 		// 配置文件不存在，创建默认配置
-		if err := m.Save(); err != nil {
+		if err := m.saveLocked(); err != nil {
 			return fmt.Errorf("无法创建默认配置: %w", err)
 		}
 	} else {
 		// 加载现有配置
-		if err := m.Load(); err != nil {
+		if err := m.loadLocked(); err != nil {
 			return fmt.Errorf("无法加载配置: %w", err)
 		}
 	}
@@ -110,6 +164,12 @@ func (m *Manager) Initialize() error {
 
 // Load 从文件中读取配置
 func (m *Manager) Load() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.loadLocked()
+}
+
+func (m *Manager) loadLocked() error {
 	data, err := os.ReadFile(m.configPath)
 	if err != nil {
 		return fmt.Errorf("无法读取配置文件: %w", err)
@@ -126,7 +186,13 @@ func (m *Manager) Load() error {
 
 // Save 将配置写入文件
 func (m *Manager) Save() error {
-	data, err := json.MarshalIndent(m.config, "", "  ")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.saveLocked()
+}
+
+func (m *Manager) saveLocked() error {
+	data, err := json.MarshalIndent(m.configLocked(), "", "  ")
 	if err != nil {
 		return fmt.Errorf("无法序列化配置: %w", err)
 	}
@@ -138,19 +204,49 @@ func (m *Manager) Save() error {
 	return nil
 }
 
+// configLocked 返回可修改的当前配置，尚未加载时先填充默认值；调用方需持有写锁
+func (m *Manager) configLocked() *Config {
+	if m.config == nil {
+		m.config = getDefaultConfig()
+	}
+	return m.config
+}
+
+// currentLocked 返回只读的当前配置；调用方需至少持有读锁
+func (m *Manager) currentLocked() *Config {
+	if m.config == nil {
+		return getDefaultConfig()
+	}
+	return m.config
+}
+
 // IsInitialized 判断配置管理器是否已初始化
 func (m *Manager) IsInitialized() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.configPath != ""
 }
 
-// GetConfig 返回当前配置
+// GetConfig 返回当前配置的副本，修改副本不会影响管理器中的配置
 func (m *Manager) GetConfig() *Config {
-	return m.config
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.currentLocked().clone()
+}
+
+// clone 返回配置的深拷贝
+func (c *Config) clone() *Config {
+	copied := *c
+	copied.Network.TLS.CAFiles = append([]string(nil), c.Network.TLS.CAFiles...)
+	copied.Network.TLS.CenterPins = append([]string(nil), c.Network.TLS.CenterPins...)
+	return &copied
 }
 
 // GetConfigJSON 以 JSON 字符串形式返回配置
 func (m *Manager) GetConfigJSON() (string, error) {
-	data, err := json.MarshalIndent(m.config, "", "  ")
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, err := json.MarshalIndent(m.currentLocked(), "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("无法将配置序列化为 JSON: %w", err)
 	}
@@ -164,8 +260,10 @@ func (m *Manager) UpdateConfig(jsonStr string) error {
 		return fmt.Errorf("无法解析配置 JSON: %w", err)
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.config = config
-	return m.Save()
+	return m.saveLocked()
 }
 
 // UpdateWindowSize 更新窗口尺寸配置
@@ -173,52 +271,81 @@ func (m *Manager) UpdateWindowSize(width, height int) error {
 	if width <= 0 || height <= 0 {
 		return nil
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.configPath == "" {
 		return nil
 	}
-	if m.config == nil {
-		m.config = getDefaultConfig()
-	}
-	if m.config.Window.Width == width && m.config.Window.Height == height {
+	config := m.configLocked()
+	if config.Window.Width == width && config.Window.Height == height {
 		return nil
 	}
 
-	m.config.Window.Width = width
-	m.config.Window.Height = height
-	return m.Save()
+	config.Window.Width = width
+	config.Window.Height = height
+	return m.saveLocked()
 }
 
 // UpdateWindowMaximised updates the window maximised state.
 func (m *Manager) UpdateWindowMaximised(maximised bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.configPath == "" {
 		return nil
 	}
-	if m.config == nil {
-		m.config = getDefaultConfig()
+	config := m.configLocked()
+	if config.Window.Maximised == maximised {
+		return nil
 	}
-	if m.config.Window.Maximised == maximised {
+
+	config.Window.Maximised = maximised
+	return m.saveLocked()
+}
+
+// UpdateProxy 更新出站代理配置
+func (m *Manager) UpdateProxy(proxy ProxyConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.configLocked().Network.Proxy = proxy
+	if m.configPath == "" {
 		return nil
 	}
+	return m.saveLocked()
+}
+
+// UpdateTLS 更新 TLS 信任配置
+func (m *Manager) UpdateTLS(tlsConfig TLSConfig) error {
+	tlsConfig.CAFiles = append([]string(nil), tlsConfig.CAFiles...)
+	tlsConfig.CenterPins = append([]string(nil), tlsConfig.CenterPins...)
 
-	m.config.Window.Maximised = maximised
-	return m.Save()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.configLocked().Network.TLS = tlsConfig
+	if m.configPath == "" {
+		return nil
+	}
+	return m.saveLocked()
 }
 
 // GetWindowSize returns the window size and maximised state.
 func (m *Manager) GetWindowSize() (int, int, bool) {
-	if m.config == nil {
-		m.config = getDefaultConfig()
-	}
-	return m.config.Window.Width, m.config.Window.Height, m.config.Window.Maximised
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	config := m.currentLocked()
+	return config.Window.Width, config.Window.Height, config.Window.Maximised
 }
 
 // GetConfigPath 返回配置文件路径
 func (m *Manager) GetConfigPath() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.configPath
 }
 
 // ResetToDefaults 重置配置为默认值
 func (m *Manager) ResetToDefaults() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.config = getDefaultConfig()
-	return m.Save()
+	return m.saveLocked()
 }
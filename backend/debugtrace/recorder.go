@@ -0,0 +1,283 @@
+// Package debugtrace records redacted HTTP requests and responses while debug mode is on.
+package debugtrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"loliashizuku/backend/httpclient"
+	"loliashizuku/backend/models"
+)
+
+const (
+	// maxEntries is the number of entries kept in memory for the viewer.
+	maxEntries = 500
+	// maxBodyBytes is the length of a recorded body after redaction.
+	maxBodyBytes = 4 << 10
+	// captureBytes is how much of a body is read for redaction. Secrets are redacted on
+	// this prefix before it is cut down to maxBodyBytes.
+	captureBytes = 64 << 10
+	// maxFileBytes rotates the trace file once it would grow past this size.
+	maxFileBytes = 5 << 20
+	// maxBackups is the number of rotated trace files kept next to the current one.
+	maxBackups = 3
+)
+
+// Recorder keeps recent entries in memory and appends them to a rotating JSON-lines file.
+type Recorder struct {
+	mu       sync.Mutex
+	enabled  func() bool
+	filePath func() (string, error)
+	entries  []models.HTTPTraceEntry
+	nextID   int64
+}
+
+// NewRecorder creates a disabled recorder writing to the path returned by filePath.
+func NewRecorder(filePath func() (string, error)) *Recorder {
+	return &Recorder{filePath: filePath}
+}
+
+// SetEnabled sets the function deciding whether requests are recorded.
+func (r *Recorder) SetEnabled(enabled func() bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = enabled
+}
+
+// Enabled reports whether requests are currently recorded.
+func (r *Recorder) Enabled() bool {
+	r.mu.Lock()
+	enabled := r.enabled
+	r.mu.Unlock()
+	return enabled != nil && enabled()
+}
+
+// FilePath returns the path of the current trace file.
+func (r *Recorder) FilePath() (string, error) {
+	if r.filePath == nil {
+		return "", fmt.Errorf("trace file path is not configured")
+	}
+	return r.filePath()
+}
+
+// Entries returns up to limit of the most recent entries, newest first, optionally
+// only those from source. A non-positive limit returns every kept entry.
+func (r *Recorder) Entries(limit int, source string) []models.HTTPTraceEntry {
+	source = strings.TrimSpace(source)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]models.HTTPTraceEntry, 0, len(r.entries))
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if source != "" && !strings.EqualFold(r.entries[i].Source, source) {
+			continue
+		}
+		result = append(result, r.entries[i])
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// Len returns the number of entries kept in memory.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// Clear drops the in-memory entries and removes the trace files.
+func (r *Recorder) Clear() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+
+	path, err := r.FilePath()
+	if err != nil {
+		return err
+	}
+	for i := 0; i <= maxBackups; i++ {
+		if err := os.Remove(backupPath(path, i)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove trace file: %w", err)
+		}
+	}
+	return nil
+}
+
+// Middleware records every round trip made through it while the recorder is enabled.
+// source names the API, e.g. "center", "github" or "oauth".
+func (r *Recorder) Middleware(source string) httpclient.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !r.Enabled() {
+				return next.RoundTrip(req)
+			}
+
+			entry := models.HTTPTraceEntry{
+				Source:         source,
+				Method:         req.Method,
+				URL:            RedactURL(req.URL),
+				Path:           httpclient.RequestPath(req),
+				RequestHeaders: RedactHeaders(req.Header),
+				RequestSize:    req.ContentLength,
+			}
+			if req.Body != nil && req.Body != http.NoBody {
+				prefix, body, complete := capture(req.Body)
+				req = req.Clone(req.Context())
+				req.Body = body
+				if complete {
+					entry.RequestSize = int64(len(prefix))
+				}
+				entry.RequestBody, entry.RequestTruncated = redactBody(prefix, !complete)
+			}
+
+			startedAt := time.Now()
+			resp, err := next.RoundTrip(req)
+			entry.StartedAt = startedAt.Format(time.RFC3339Nano)
+			entry.DurationMs = time.Since(startedAt).Milliseconds()
+			if err != nil {
+				entry.Error = RedactBody(err.Error())
+			}
+			if resp != nil {
+				entry.StatusCode = resp.StatusCode
+				entry.ResponseHeaders = RedactHeaders(resp.Header)
+				entry.ResponseSize = resp.ContentLength
+				if resp.Body != nil && resp.Body != http.NoBody {
+					var prefix []byte
+					var complete bool
+					prefix, resp.Body, complete = capture(resp.Body)
+					if complete {
+						entry.ResponseSize = int64(len(prefix))
+					}
+					entry.ResponseBody, entry.ResponseTruncated = redactBody(prefix, !complete)
+				}
+			}
+
+			r.record(entry)
+			return resp, err
+		})
+	}
+}
+
+func (r *Recorder) record(entry models.HTTPTraceEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	entry.ID = r.nextID
+	r.entries = append(r.entries, entry)
+	if len(r.entries) > maxEntries {
+		r.entries = append([]models.HTTPTraceEntry(nil), r.entries[len(r.entries)-maxEntries:]...)
+	}
+
+	// Tracing must never break a request, so file errors are ignored.
+	_ = r.appendToFile(entry)
+}
+
+func (r *Recorder) appendToFile(entry models.HTTPTraceEntry) error {
+	path, err := r.FilePath()
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal trace entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create trace dir: %w", err)
+	}
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > maxFileBytes {
+		if err := rotate(path); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open trace file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(line); err != nil {
+		return fmt.Errorf("write trace file: %w", err)
+	}
+	return nil
+}
+
+// rotate shifts path to path.1, path.1 to path.2 and so on, dropping the oldest backup.
+func rotate(path string) error {
+	for i := maxBackups; i >= 1; i-- {
+		from := backupPath(path, i-1)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if err := os.Rename(from, backupPath(path, i)); err != nil {
+			return fmt.Errorf("rotate trace file: %w", err)
+		}
+	}
+	return nil
+}
+
+func backupPath(path string, index int) string {
+	if index == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s.%d", path, index)
+}
+
+// capture reads up to captureBytes of body and returns them together with a body that
+// still yields the full content. complete reports whether the whole body was read.
+func capture(body io.ReadCloser) ([]byte, io.ReadCloser, bool) {
+	prefix, err := io.ReadAll(io.LimitReader(body, captureBytes+1))
+	if err != nil {
+		return prefix, readCloser{Reader: io.MultiReader(bytes.NewReader(prefix), errReader{err}), Closer: body}, false
+	}
+	if len(prefix) <= captureBytes {
+		body.Close()
+		return prefix, io.NopCloser(bytes.NewReader(prefix)), true
+	}
+	return prefix, readCloser{Reader: io.MultiReader(bytes.NewReader(prefix), body), Closer: body}, false
+}
+
+// redactBody redacts the captured body and cuts it down to maxBodyBytes.
+func redactBody(payload []byte, partial bool) (string, bool) {
+	if len(payload) == 0 {
+		return "", false
+	}
+	if bytes.IndexByte(payload[:min(len(payload), 512)], 0) >= 0 {
+		return fmt.Sprintf("<binary, %d bytes captured>", len(payload)), true
+	}
+	text := RedactBody(string(payload))
+	if len(text) <= maxBodyBytes {
+		return text, partial
+	}
+	cut := maxBodyBytes
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut], true
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,94 @@
+package debugtrace
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// secretFields are JSON keys, form fields and query parameters whose values are never recorded.
+var secretFields = []string{
+	"access_token",
+	"refresh_token",
+	"tunnel_token",
+	"id_token",
+	"client_secret",
+	"code_verifier",
+	"device_code",
+}
+
+// secretParams are form fields and query parameters redacted on top of secretFields.
+// "code" carries the OAuth authorization code; as a JSON key it is the numeric
+// business code of every Center API response and stays visible.
+var secretParams = append([]string{"code"}, secretFields...)
+
+// secretHeaders are headers whose values are never recorded.
+var secretHeaders = map[string]struct{}{
+	"Authorization":       {},
+	"Proxy-Authorization": {},
+	"Cookie":              {},
+	"Set-Cookie":          {},
+}
+
+var (
+	fieldPattern = strings.Join(secretFields, "|")
+	paramPattern = strings.Join(secretParams, "|")
+	// "access_token": "value"
+	jsonSecretRe = regexp.MustCompile(`("(?:` + fieldPattern + `)"\s*:\s*")(?:[^"\\]|\\.)*"`)
+	// \"access_token\": \"value\" inside a JSON-encoded string.
+	escapedJSONSecretRe = regexp.MustCompile(`(\\"(?:` + fieldPattern + `)\\"\s*:\s*\\")(?:[^"\\]|\\\\)*?\\"`)
+	// access_token=value in form bodies and query strings.
+	formSecretRe = regexp.MustCompile(`((?:^|[&?\s])(?:` + paramPattern + `)=)[^&\s]*`)
+	// auth.token = "value" in frpc configs, raw or inside a JSON-encoded string.
+	frpcTokenRe        = regexp.MustCompile(`(\b(?:auth\.)?token\s*=\s*")(?:[^"\\]|\\.)*"`)
+	escapedFrpcTokenRe = regexp.MustCompile(`((?:^|\\n|[\s;,{])(?:auth\.)?token\s*=\s*\\")(?:[^"\\]|\\\\)*?\\"`)
+)
+
+// RedactHeaders returns a copy of headers with secret values replaced.
+func RedactHeaders(headers http.Header) map[string]string {
+	result := make(map[string]string, len(headers))
+	for key, values := range headers {
+		if _, secret := secretHeaders[http.CanonicalHeaderKey(key)]; secret {
+			result[key] = redacted
+			continue
+		}
+		result[key] = strings.Join(values, ", ")
+	}
+	return result
+}
+
+// RedactURL replaces secret query parameter values.
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	copied := *u
+	copied.User = nil
+	if copied.RawQuery != "" {
+		query := copied.Query()
+		changed := false
+		for _, field := range secretParams {
+			if query.Has(field) {
+				query.Set(field, redacted)
+				changed = true
+			}
+		}
+		if changed {
+			copied.RawQuery = query.Encode()
+		}
+	}
+	return copied.String()
+}
+
+// RedactBody replaces secret values in JSON, form-encoded and frpc config text.
+func RedactBody(body string) string {
+	body = jsonSecretRe.ReplaceAllString(body, `${1}`+redacted+`"`)
+	body = escapedJSONSecretRe.ReplaceAllString(body, `${1}`+redacted+`\"`)
+	body = formSecretRe.ReplaceAllString(body, `${1}`+redacted)
+	body = frpcTokenRe.ReplaceAllString(body, `${1}`+redacted+`"`)
+	body = escapedFrpcTokenRe.ReplaceAllString(body, `${1}`+redacted+`\"`)
+	return body
+}
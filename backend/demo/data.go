@@ -0,0 +1,423 @@
+package demo
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"loliashizuku/backend/models"
+)
+
+const (
+	gib = int64(1) << 30
+	mib = int64(1) << 20
+
+	demoUserID        = 10001
+	demoHistoryDays   = 90
+	demoTrafficLimit  = 200 * gib
+	demoCheckInReward = 2 * gib
+	demoFrpsVersion   = "0.61.1"
+	demoClientVersion = "1.2.0"
+)
+
+type demoNode struct {
+	name      string
+	online    bool
+	needKYC   bool
+	sponsor   string
+	bandwidth int64
+	protocols []string
+}
+
+var demoNodes = []demoNode{
+	{name: "香港 BGP 1", online: true, sponsor: "LoliaFRP", bandwidth: 100, protocols: []string{"tcp", "udp", "http", "https"}},
+	{name: "东京 IIJ", online: true, sponsor: "LoliaFRP", bandwidth: 200, protocols: []string{"tcp", "udp", "http", "https"}},
+	{name: "洛杉矶 9929", online: true, sponsor: "社区赞助", bandwidth: 50, protocols: []string{"tcp", "udp"}},
+	{name: "上海 电信", online: true, needKYC: true, sponsor: "LoliaFRP", bandwidth: 30, protocols: []string{"tcp", "http", "https"}},
+	{name: "新加坡 AWS", online: true, sponsor: "社区赞助", bandwidth: 100, protocols: []string{"tcp", "udp", "http", "https"}},
+	{name: "法兰克福 Hetzner", online: false, sponsor: "LoliaFRP", bandwidth: 100, protocols: []string{"tcp", "udp"}},
+}
+
+type demoTunnel struct {
+	name      string
+	remark    string
+	kind      string
+	localPort int64
+	node      int
+	domain    string
+	active    bool
+	// weight scales the tunnel's share of the seeded daily traffic.
+	weight float64
+}
+
+var demoTunnels = []demoTunnel{
+	{name: "minecraft", remark: "MC 服务器", kind: "tcp", localPort: 25565, node: 0, active: true, weight: 6},
+	{name: "blog", remark: "个人博客", kind: "https", localPort: 8080, node: 1, domain: "blog.demo.lolia.link", active: true, weight: 3},
+	{name: "nas-web", remark: "NAS 管理页", kind: "http", localPort: 5000, node: 0, domain: "nas.demo.lolia.link", active: true, weight: 1.5},
+	{name: "ssh-home", remark: "家里 SSH", kind: "tcp", localPort: 22, node: 0, active: true, weight: 0.2},
+	{name: "rdp-office", remark: "办公室远程桌面", kind: "tcp", localPort: 3389, node: 3, active: false, weight: 1},
+	{name: "palworld", remark: "幻兽帕鲁", kind: "udp", localPort: 8211, node: 1, active: true, weight: 4},
+	{name: "jellyfin", remark: "影音库", kind: "https", localPort: 8096, node: 4, domain: "media.demo.lolia.link", active: true, weight: 8},
+	{name: "git", remark: "Gitea", kind: "http", localPort: 3000, node: 1, domain: "git.demo.lolia.link", active: true, weight: 0.8},
+	{name: "dns-test", remark: "", kind: "udp", localPort: 53, node: 2, active: false, weight: 0.1},
+	{name: "api-dev", remark: "开发环境 API", kind: "http", localPort: 8000, node: 4, domain: "api.demo.lolia.link", active: true, weight: 0.6},
+	{name: "terraria", remark: "泰拉瑞亚", kind: "tcp", localPort: 7777, node: 2, active: false, weight: 0.5},
+	{name: "mysql", remark: "测试数据库", kind: "tcp", localPort: 3306, node: 5, active: false, weight: 0.3},
+	{name: "vscode", remark: "code-server", kind: "https", localPort: 8443, node: 1, domain: "code.demo.lolia.link", active: true, weight: 0.7},
+	{name: "webdav", remark: "文件同步", kind: "http", localPort: 5005, node: 0, domain: "dav.demo.lolia.link", active: true, weight: 2},
+}
+
+// dataset is the mutable state behind the fake Center API. It is seeded
+// deterministically so every demo session shows the same account.
+type dataset struct {
+	mu sync.Mutex
+
+	user      models.UserInfoData
+	nodes     []models.NodeItem
+	tunnels   []models.TunnelDetailData
+	daily     []models.DailyTrafficStat
+	perTunnel map[string]*models.TrafficTunnelItem
+	checkedOn string
+}
+
+func newDataset(now time.Time, nodeHost string, nodePort int) *dataset {
+	rng := rand.New(rand.NewPCG(20240101, demoUserID))
+	createdAt := now.AddDate(-1, -2, 0).UTC()
+
+	data := &dataset{
+		user: models.UserInfoData{
+			Avatar:         "",
+			BandwidthLimit: 20,
+			CreatedAt:      createdAt.Format(time.RFC3339),
+			Email:          "demo@lolia.link",
+			HasKYC:         true,
+			ID:             demoUserID,
+			KYCStatus:      "verified",
+			MaxTunnelCount: 20,
+			Role:           "user",
+			TrafficLimit:   demoTrafficLimit,
+			TunnelToken:    "demo-user-token",
+			Username:       "shizuku-demo",
+		},
+		perTunnel: map[string]*models.TrafficTunnelItem{},
+	}
+
+	for i, node := range demoNodes {
+		status := "online"
+		port := int64(nodePort)
+		if !node.online {
+			status = "offline"
+			// Nothing listens on port 1, so probes of offline nodes fail like the real thing.
+			port = 1
+		}
+		data.nodes = append(data.nodes, models.NodeItem{
+			ID:                 int64(i + 1),
+			Name:               node.name,
+			Status:             status,
+			IPAddress:          nodeHost,
+			SupportedProtocols: node.protocols,
+			NeedKYC:            node.needKYC,
+			FrpsVersion:        demoFrpsVersion,
+			AgentVersion:       "1.0.3",
+			FrpsPort:           port,
+			Sponsor:            node.sponsor,
+			Bandwidth:          node.bandwidth,
+			LastSeen:           now.Add(-time.Duration(rng.IntN(60)) * time.Second).UTC().Format(time.RFC3339),
+			CreatedAt:          createdAt.AddDate(0, -i, 0).Format(time.RFC3339),
+		})
+	}
+
+	for i, tunnel := range demoTunnels {
+		status := "inactive"
+		if tunnel.active {
+			status = "active"
+		}
+		remotePort := int64(0)
+		if tunnel.kind == "tcp" || tunnel.kind == "udp" {
+			remotePort = int64(20000 + rng.IntN(40000))
+		}
+		node := data.nodes[tunnel.node]
+		data.tunnels = append(data.tunnels, models.TunnelDetailData{
+			BandwidthLimit: 10,
+			ClientVersion:  demoFrpsVersion,
+			CreatedAt:      createdAt.AddDate(0, 0, i*17).Format(time.RFC3339),
+			CustomDomain:   tunnel.domain,
+			ID:             int64(5000 + i),
+			LocalIP:        "127.0.0.1",
+			LocalPort:      tunnel.localPort,
+			Name:           tunnel.name,
+			NodeAddress:    node.IPAddress,
+			NodeID:         node.ID,
+			NodeName:       node.Name,
+			Remark:         tunnel.remark,
+			RemotePort:     remotePort,
+			Status:         status,
+			TunnelToken:    fmt.Sprintf("demo-%s-%04d", tunnel.name, rng.IntN(10000)),
+			Type:           tunnel.kind,
+		})
+	}
+
+	data.seedTraffic(now, rng)
+	return data
+}
+
+// seedTraffic generates demoHistoryDays of per-tunnel traffic with a weekly cycle,
+// a slow upward trend and some noise, ending with today's partial day.
+func (d *dataset) seedTraffic(now time.Time, rng *rand.Rand) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	var used int64
+
+	for offset := demoHistoryDays - 1; offset >= 0; offset-- {
+		date := today.AddDate(0, 0, -offset)
+		trend := 1 + float64(demoHistoryDays-offset)/float64(demoHistoryDays)*0.4
+		weekend := 1.0
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			weekend = 1.6
+		}
+		dayFraction := 1.0
+		if offset == 0 {
+			dayFraction = float64(now.Hour()*60+now.Minute()) / (24 * 60)
+		}
+
+		stat := models.DailyTrafficStat{Date: date.Format("2006-01-02")}
+		for _, tunnel := range demoTunnels {
+			if !tunnel.active && rng.Float64() < 0.7 {
+				continue
+			}
+			noise := 0.6 + rng.Float64()*0.8
+			bytes := int64(tunnel.weight * 90 * float64(mib) * trend * weekend * noise * dayFraction)
+			if bytes <= 0 {
+				continue
+			}
+			stat.TunnelStats = append(stat.TunnelStats, models.DailyTunnelStat{
+				TunnelName:   tunnel.name,
+				Remark:       tunnel.remark,
+				TotalTraffic: bytes,
+			})
+			stat.TotalTraffic += bytes
+
+			item, ok := d.perTunnel[tunnel.name]
+			if !ok {
+				item = &models.TrafficTunnelItem{
+					TunnelName: tunnel.name,
+					NodeID:     fmt.Sprint(tunnel.node + 1),
+					Remark:     tunnel.remark,
+				}
+				d.perTunnel[tunnel.name] = item
+			}
+			in := bytes * int64(30+rng.IntN(40)) / 100
+			item.TotalIn += in
+			item.TotalOut += bytes - in
+			item.TotalTraffic += bytes
+			item.MaxConnection = max(item.MaxConnection, int64(1+rng.IntN(64)))
+		}
+		if date.Month() == today.Month() && date.Year() == today.Year() {
+			used += stat.TotalTraffic
+		}
+		d.daily = append(d.daily, stat)
+	}
+	d.user.TrafficUsed = used
+}
+
+func (d *dataset) userInfo() models.UserInfoData {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	user := d.user
+	user.TodayChecked = d.checkedOn == time.Now().Format("2006-01-02")
+	return user
+}
+
+func (d *dataset) trafficStats() models.UserTrafficData {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return models.UserTrafficData{
+		UserID:           fmt.Sprint(d.user.ID),
+		Username:         d.user.Username,
+		TrafficLimit:     d.user.TrafficLimit,
+		TrafficUsed:      d.user.TrafficUsed,
+		TrafficRemaining: max(d.user.TrafficLimit-d.user.TrafficUsed, 0),
+	}
+}
+
+func (d *dataset) nodeList() map[string]any {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return map[string]any{
+		"nodes": d.nodes,
+		"total": len(d.nodes),
+		"page":  1,
+		"limit": len(d.nodes),
+	}
+}
+
+func (d *dataset) tunnelPage(page, limit int) models.TunnelListData {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+	total := len(d.tunnels)
+	totalPage := (total + limit - 1) / limit
+	start := min((page-1)*limit, total)
+	end := min(start+limit, total)
+
+	list := make([]models.TunnelItem, 0, end-start)
+	for _, tunnel := range d.tunnels[start:end] {
+		item := models.TunnelItem{
+			BandwidthLimit: tunnel.BandwidthLimit,
+			CustomDomain:   tunnel.CustomDomain,
+			ID:             tunnel.ID,
+			LocalIP:        tunnel.LocalIP,
+			LocalPort:      tunnel.LocalPort,
+			Name:           tunnel.Name,
+			NodeID:         tunnel.NodeID,
+			Remark:         tunnel.Remark,
+			RemotePort:     tunnel.RemotePort,
+			Status:         tunnel.Status,
+			Type:           tunnel.Type,
+			CreatedAt:      tunnel.CreatedAt,
+		}
+		if traffic, ok := d.perTunnel[tunnel.Name]; ok {
+			item.TotalIn = traffic.TotalIn
+			item.TotalOut = traffic.TotalOut
+			item.TotalTraffic = traffic.TotalTraffic
+		}
+		list = append(list, item)
+	}
+	return models.TunnelListData{
+		Limit:     int64(limit),
+		List:      list,
+		Page:      int64(page),
+		Total:     int64(total),
+		TotalPage: int64(totalPage),
+	}
+}
+
+func (d *dataset) tunnel(name string) (models.TunnelDetailData, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, tunnel := range d.tunnels {
+		if tunnel.Name == name {
+			return tunnel, true
+		}
+	}
+	return models.TunnelDetailData{}, false
+}
+
+func (d *dataset) tunnelByToken(id int64, token string) (models.TunnelDetailData, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, tunnel := range d.tunnels {
+		if tunnel.ID == id && tunnel.TunnelToken == token {
+			return tunnel, true
+		}
+	}
+	return models.TunnelDetailData{}, false
+}
+
+// recentDaily returns the last days entries, newest last.
+func (d *dataset) recentDaily(days int) models.DailyTrafficResponse {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	days = clampDays(days)
+	stats := append([]models.DailyTrafficStat(nil), d.daily[len(d.daily)-days:]...)
+	return models.DailyTrafficResponse{Days: int64(days), DailyStats: stats}
+}
+
+// trafficTunnels sums the last days of daily traffic per tunnel.
+func (d *dataset) trafficTunnels(days int) models.TrafficTunnelData {
+	daily := d.recentDaily(days)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sums := map[string]int64{}
+	for _, stat := range daily.DailyStats {
+		for _, tunnel := range stat.TunnelStats {
+			sums[tunnel.TunnelName] += tunnel.TotalTraffic
+		}
+	}
+
+	data := models.TrafficTunnelData{Days: daily.Days, Tunnels: []models.TrafficTunnelItem{}}
+	for _, tunnel := range demoTunnels {
+		total, ok := sums[tunnel.name]
+		if !ok {
+			continue
+		}
+		item := *d.perTunnel[tunnel.name]
+		ratio := float64(total) / float64(max(item.TotalTraffic, 1))
+		item.TotalIn = int64(float64(item.TotalIn) * ratio)
+		item.TotalOut = total - item.TotalIn
+		item.TotalTraffic = total
+		data.Tunnels = append(data.Tunnels, item)
+	}
+	data.Count = int64(len(data.Tunnels))
+	return data
+}
+
+func (d *dataset) checkIn(now time.Time) models.CheckInResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	today := now.Format("2006-01-02")
+	if d.checkedOn == today {
+		return models.CheckInResult{
+			TrafficLimit: d.user.TrafficLimit,
+			Message:      "今日已签到",
+		}
+	}
+	d.checkedOn = today
+	d.user.TrafficLimit += demoCheckInReward
+	return models.CheckInResult{
+		Reward:       demoCheckInReward,
+		TrafficLimit: d.user.TrafficLimit,
+		Message:      "签到成功",
+	}
+}
+
+func (d *dataset) frpcConfig(name string) (string, bool) {
+	tunnel, ok := d.tunnel(name)
+	if !ok {
+		return "", false
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "serverAddr = %q\n", tunnel.NodeAddress)
+	fmt.Fprintf(&b, "serverPort = %d\n", d.nodes[tunnel.NodeID-1].FrpsPort)
+	fmt.Fprintf(&b, "auth.method = \"token\"\n")
+	fmt.Fprintf(&b, "auth.token = %q\n\n", fmt.Sprintf("%d:%s", tunnel.ID, tunnel.TunnelToken))
+	fmt.Fprintf(&b, "[[proxies]]\n")
+	fmt.Fprintf(&b, "name = %q\n", tunnel.Name)
+	fmt.Fprintf(&b, "type = %q\n", tunnel.Type)
+	fmt.Fprintf(&b, "localIP = %q\n", tunnel.LocalIP)
+	fmt.Fprintf(&b, "localPort = %d\n", tunnel.LocalPort)
+	if tunnel.RemotePort > 0 {
+		fmt.Fprintf(&b, "remotePort = %d\n", tunnel.RemotePort)
+	}
+	if tunnel.CustomDomain != "" {
+		fmt.Fprintf(&b, "customDomains = [%q]\n", tunnel.CustomDomain)
+	}
+	return b.String(), true
+}
+
+func (d *dataset) homeStats() models.HomeStatsData {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return models.HomeStatsData{
+		UserCount:        12873,
+		TunnelCount:      31466,
+		TotalTrafficUsed: 1843 * 1024 * gib,
+	}
+}
+
+func clampDays(days int) int {
+	if days <= 0 {
+		return 7
+	}
+	return min(days, demoHistoryDays)
+}
@@ -0,0 +1,125 @@
+package demo
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	demoDeviceCodeTTL  = 10 * time.Minute
+	demoDeviceInterval = 2 * time.Second
+	deviceCodeGrant    = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+type deviceGrant struct {
+	userCode   string
+	expiresAt  time.Time
+	interval   time.Duration
+	lastPollAt time.Time
+	approved   bool
+}
+
+// deviceGrants is the state of the RFC 8628 device authorization endpoint.
+type deviceGrants struct {
+	mu     sync.Mutex
+	next   int
+	grants map[string]*deviceGrant
+}
+
+func newDeviceGrants() *deviceGrants {
+	return &deviceGrants{grants: map[string]*deviceGrant{}}
+}
+
+// handleDeviceAuthorization issues a device code whose user code is approved by
+// opening the verification page.
+func (s *Server) handleDeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	d := s.devices
+	d.mu.Lock()
+	d.next++
+	deviceCode := fmt.Sprintf("demo-device-code-%d", d.next)
+	userCode := fmt.Sprintf("DEMO-%04d", 1144+d.next)
+	d.grants[deviceCode] = &deviceGrant{
+		userCode:  userCode,
+		expiresAt: time.Now().Add(demoDeviceCodeTTL),
+		interval:  demoDeviceInterval,
+	}
+	d.mu.Unlock()
+
+	verificationURI := s.URL() + "/oauth/device"
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"device_code":               deviceCode,
+		"user_code":                 userCode,
+		"verification_uri":          verificationURI,
+		"verification_uri_complete": verificationURI + "?user_code=" + userCode,
+		"expires_in":                int(demoDeviceCodeTTL.Seconds()),
+		"interval":                  int(demoDeviceInterval.Seconds()),
+	})
+}
+
+// handleDeviceVerify approves the user code given in the query, or asks for one.
+func (s *Server) handleDeviceVerify(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	userCode := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("user_code")))
+	if userCode == "" {
+		_, _ = w.Write([]byte(`<html><body><form method="get"><label>User code <input name="user_code"></label> <button type="submit">Approve</button></form></body></html>`))
+		return
+	}
+
+	d := s.devices
+	d.mu.Lock()
+	approved := false
+	for _, grant := range d.grants {
+		if grant.userCode == userCode && time.Now().Before(grant.expiresAt) {
+			grant.approved = true
+			approved = true
+		}
+	}
+	d.mu.Unlock()
+
+	if !approved {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, `<html><body><h3>Unknown or expired code %s</h3></body></html>`, html.EscapeString(userCode))
+		return
+	}
+	_, _ = w.Write([]byte(`<html><body><h3>Device approved</h3><p>You can return to the app now.</p></body></html>`))
+}
+
+// pollDeviceToken answers a device_code grant. It reports whether the token may be
+// issued, writing the RFC 8628 error otherwise. Polling faster than the advertised
+// interval gets slow_down.
+func (s *Server) pollDeviceToken(w http.ResponseWriter, deviceCode string) bool {
+	d := s.devices
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	grant, ok := d.grants[deviceCode]
+	switch {
+	case !ok:
+		writeOAuthError(w, "invalid_grant")
+		return false
+	case time.Now().After(grant.expiresAt):
+		delete(d.grants, deviceCode)
+		writeOAuthError(w, "expired_token")
+		return false
+	}
+
+	now := time.Now()
+	tooFast := !grant.lastPollAt.IsZero() && now.Sub(grant.lastPollAt) < grant.interval
+	grant.lastPollAt = now
+	switch {
+	case tooFast:
+		writeOAuthError(w, "slow_down")
+		return false
+	case !grant.approved:
+		writeOAuthError(w, "authorization_pending")
+		return false
+	}
+	delete(d.grants, deviceCode)
+	return true
+}
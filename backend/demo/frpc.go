@@ -0,0 +1,387 @@
+package demo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"loliashizuku/backend/models"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	stubBinaryName    = "frpc"
+	stubVersion       = demoFrpsVersion + "-demo"
+	stubHeartbeatTick = 30 * time.Second
+	demoReleaseTag    = "v" + stubVersion
+)
+
+// IsFrpcStub reports whether the process was started as the fake frpc binary.
+// The demo release ships a copy of the application named frpc, so the binary
+// decides what to be from its own file name, busybox style.
+func IsFrpcStub(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	name := strings.TrimSuffix(filepath.Base(args[0]), ".exe")
+	return strings.EqualFold(name, stubBinaryName)
+}
+
+// RunFrpcStub imitates the frpc command line used by the client and returns the exit code.
+// It understands -v, verify -c <file> and -t <id>:<token>; a running stub logs like
+// frpc until interrupted.
+func RunFrpcStub(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: frpc -t <id>:<token>")
+		return 1
+	}
+
+	switch args[0] {
+	case "-v", "--version", "version":
+		fmt.Println("frpc", stubVersion)
+		return 0
+	case "verify":
+		return runStubVerify(args[1:])
+	case "-t":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "flag needs an argument: -t")
+			return 1
+		}
+		return runStubTunnel(args[1])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown flag: %s\n", args[0])
+		return 1
+	}
+}
+
+func runStubTunnel(tokenArg string) int {
+	id, _, ok := strings.Cut(tokenArg, ":")
+	if !ok || strings.TrimSpace(id) == "" {
+		stubLog("E", "client/service.go:295", "invalid token format, expected <id>:<token>")
+		return 1
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	stubLog("I", "sub/root.go:142", "start frpc service for config file [token mode] (demo)")
+	stubLog("I", "client/service.go:295", "try to connect to server...")
+	time.Sleep(300 * time.Millisecond)
+	stubLog("I", "client/service.go:287", fmt.Sprintf("[demo-%s] login to server success, get run id [demo%s]", id, id))
+	stubLog("I", "proxy/proxy_manager.go:173", fmt.Sprintf("[demo-%s] proxy added: [tunnel-%s]", id, id))
+	stubLog("I", "client/control.go:168", fmt.Sprintf("[demo-%s] [tunnel-%s] start proxy success", id, id))
+
+	ticker := time.NewTicker(stubHeartbeatTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-signals:
+			stubLog("I", "client/service.go:339", "frpc service is stopping")
+			return 0
+		case <-ticker.C:
+			stubLog("D", "client/control.go:240", fmt.Sprintf("[demo-%s] send heartbeat to server", id))
+		}
+	}
+}
+
+// runStubVerify checks the parts of an frpc TOML config that the client generates.
+func runStubVerify(args []string) int {
+	var path string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-c" || args[i] == "--config":
+			if i+1 < len(args) {
+				path = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(args[i], "-c="), strings.HasPrefix(args[i], "--config="):
+			_, path, _ = strings.Cut(args[i], "=")
+		}
+	}
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "frpc: config file is required (-c)")
+		return 1
+	}
+
+	var config struct {
+		ServerAddr string `toml:"serverAddr"`
+		ServerPort int    `toml:"serverPort"`
+		Auth       struct {
+			Method string `toml:"method"`
+			Token  string `toml:"token"`
+		} `toml:"auth"`
+		Proxies []struct {
+			Name          string   `toml:"name"`
+			Type          string   `toml:"type"`
+			LocalIP       string   `toml:"localIP"`
+			LocalPort     int      `toml:"localPort"`
+			RemotePort    int      `toml:"remotePort"`
+			CustomDomains []string `toml:"customDomains"`
+		} `toml:"proxies"`
+	}
+	meta, err := toml.DecodeFile(path, &config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "frpc: %v\n", err)
+		return 1
+	}
+	var problems []string
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown field %q", undecoded[0].String()))
+	}
+	if config.ServerAddr == "" || config.ServerPort <= 0 {
+		problems = append(problems, "serverAddr and serverPort are required")
+	}
+	if config.Auth.Method == "token" && config.Auth.Token == "" {
+		problems = append(problems, "auth.token is required")
+	}
+	for _, proxy := range config.Proxies {
+		switch {
+		case proxy.Name == "":
+			problems = append(problems, "proxy name is required")
+		case proxy.LocalPort <= 0:
+			problems = append(problems, fmt.Sprintf("proxy [%s]: localPort is required", proxy.Name))
+		case (proxy.Type == "tcp" || proxy.Type == "udp") && proxy.RemotePort <= 0:
+			problems = append(problems, fmt.Sprintf("proxy [%s]: remotePort is required", proxy.Name))
+		case (proxy.Type == "http" || proxy.Type == "https") && len(proxy.CustomDomains) == 0:
+			problems = append(problems, fmt.Sprintf("proxy [%s]: customDomains is required", proxy.Name))
+		}
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "frpc: verify config %s failed: %s\n", path, strings.Join(problems, "; "))
+		return 1
+	}
+	fmt.Printf("frpc: the configuration file %s syntax is ok\n", path)
+	return 0
+}
+
+func stubLog(level, source, message string) {
+	fmt.Printf("%s [%s] [%s] %s\n", time.Now().Format("2006-01-02 15:04:05.000"), level, source, message)
+}
+
+// releaseFeed serves a GitHub-style "latest release" whose only asset is an archive
+// containing the fake frpc binary for the current platform. Like GitHub it sends an
+// ETag and X-RateLimit-* headers, and 304 responses do not use quota.
+type releaseFeed struct {
+	baseURL string
+
+	once    sync.Once
+	archive []byte
+	digest  string
+	err     error
+
+	quotaMu    sync.Mutex
+	quotaReset time.Time
+	quotaUsed  map[bool]int
+}
+
+// Demo quotas match GitHub's anonymous and authenticated core limits.
+const (
+	demoAnonymousQuota = 60
+	demoTokenQuota     = 5000
+	// demoRejectedToken is refused by the rate limit endpoint, to try the invalid token path.
+	demoRejectedToken = "invalid"
+)
+
+func newReleaseFeed(baseURL string) *releaseFeed {
+	return &releaseFeed{baseURL: baseURL}
+}
+
+func (f *releaseFeed) assetName() (string, string) {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf("LoliaFrp_%s_%s.zip", runtime.GOOS, runtime.GOARCH), "zip"
+	}
+	return fmt.Sprintf("LoliaFrp_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH), "tar.gz"
+}
+
+// build packs a copy of the running executable, renamed to frpc, into the release
+// archive. It runs once, on the first release request.
+func (f *releaseFeed) build() error {
+	f.once.Do(func() {
+		executable, err := os.Executable()
+		if err != nil {
+			f.err = fmt.Errorf("resolve executable: %w", err)
+			return
+		}
+		binary, err := os.ReadFile(executable)
+		if err != nil {
+			f.err = fmt.Errorf("read executable: %w", err)
+			return
+		}
+
+		binaryName := stubBinaryName
+		if runtime.GOOS == "windows" {
+			binaryName += ".exe"
+		}
+		_, format := f.assetName()
+		if format == "zip" {
+			f.archive, f.err = zipArchive(binaryName, binary)
+		} else {
+			f.archive, f.err = tarGzArchive(binaryName, binary)
+		}
+		if f.err == nil {
+			sum := sha256.Sum256(f.archive)
+			f.digest = hex.EncodeToString(sum[:])
+		}
+	})
+	return f.err
+}
+
+func (f *releaseFeed) handleLatest(w http.ResponseWriter, r *http.Request) {
+	if err := f.build(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name, format := f.assetName()
+	contentType := "application/gzip"
+	if format == "zip" {
+		contentType = "application/zip"
+	}
+	release := models.GitHubRelease{
+		TagName:     demoReleaseTag,
+		Name:        "LoliaFrp " + demoReleaseTag + " (demo)",
+		HTMLURL:     f.baseURL + "/github/releases/" + demoReleaseTag,
+		PublishedAt: time.Now().AddDate(0, 0, -3).UTC().Format(time.RFC3339),
+		Assets: []models.GitHubReleaseAsset{{
+			Name:               name,
+			BrowserDownloadURL: f.baseURL + "/github/download/" + name,
+			Size:               int64(len(f.archive)),
+			ContentType:        contentType,
+			Digest:             "sha256:" + f.digest,
+		}},
+	}
+
+	etag := `"` + f.digest[:16] + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		f.writeQuota(w, r, false)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if !f.writeQuota(w, r, true) {
+		http.Error(w, `{"message":"API rate limit exceeded"}`, http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(release)
+}
+
+func (f *releaseFeed) handleRateLimit(w http.ResponseWriter, r *http.Request) {
+	if bearerToken(r) == demoRejectedToken {
+		http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+		return
+	}
+	f.writeQuota(w, r, false)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"resources":{}}`))
+}
+
+// writeQuota sets the X-RateLimit-* headers, first using one request of quota when
+// consume is set. It reports false when the quota is exhausted.
+func (f *releaseFeed) writeQuota(w http.ResponseWriter, r *http.Request, consume bool) bool {
+	authenticated := bearerToken(r) != ""
+	limit := demoAnonymousQuota
+	if authenticated {
+		limit = demoTokenQuota
+	}
+
+	f.quotaMu.Lock()
+	now := time.Now()
+	if f.quotaUsed == nil || now.After(f.quotaReset) {
+		f.quotaUsed = map[bool]int{}
+		f.quotaReset = now.Add(time.Hour)
+	}
+	allowed := f.quotaUsed[authenticated] < limit
+	if consume && allowed {
+		f.quotaUsed[authenticated]++
+	}
+	used := f.quotaUsed[authenticated]
+	reset := f.quotaReset
+	f.quotaMu.Unlock()
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(limit-used))
+	w.Header().Set("X-RateLimit-Used", strconv.Itoa(used))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	w.Header().Set("X-RateLimit-Resource", "core")
+	return allowed
+}
+
+func bearerToken(r *http.Request) string {
+	return strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+}
+
+func (f *releaseFeed) handleDownload(w http.ResponseWriter, r *http.Request) {
+	name, _ := f.assetName()
+	if r.PathValue("asset") != name {
+		http.NotFound(w, r)
+		return
+	}
+	if err := f.build(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(f.archive))
+}
+
+func tarGzArchive(name string, content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o755,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return nil, fmt.Errorf("write tar header: %w", err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return nil, fmt.Errorf("write tar entry: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("close tar archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("close gzip archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func zipArchive(name string, content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	header.SetMode(0o755)
+	entry, err := zw.CreateHeader(header)
+	if err != nil {
+		return nil, fmt.Errorf("create zip entry: %w", err)
+	}
+	if _, err := io.Copy(entry, bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("write zip entry: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("close zip archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,280 @@
+// Package demo implements an in-process fake of the LoliaFRP services so the client
+// can run offline without a real account: a Center API with seeded data, an OAuth
+// authorize/token/device endpoint, a GitHub-style release feed and a fake frpc binary.
+package demo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	centerAPIPrefix  = "/api/v1"
+	demoAccessToken  = "demo-access-token"
+	demoRefreshToken = "demo-refresh-token"
+	demoTokenTTL     = time.Hour
+)
+
+// Server is the fake backend. It listens on a random loopback port.
+type Server struct {
+	listener net.Listener
+	server   *http.Server
+	data     *dataset
+	release  *releaseFeed
+	devices  *deviceGrants
+}
+
+// Start launches the fake backend on 127.0.0.1 with a random port.
+func Start() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen demo server: %w", err)
+	}
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	s := &Server{
+		listener: listener,
+		data:     newDataset(time.Now(), "127.0.0.1", port),
+		devices:  newDeviceGrants(),
+	}
+	s.release = newReleaseFeed(s.URL())
+	s.server = &http.Server{
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		_ = s.server.Serve(listener)
+	}()
+	return s, nil
+}
+
+// Close stops the fake backend.
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+// URL returns the server origin, e.g. http://127.0.0.1:54321.
+func (s *Server) URL() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// CenterAPIBaseURL is the value for LOLIA_CENTER_API_BASE_URL.
+func (s *Server) CenterAPIBaseURL() string {
+	return s.URL() + centerAPIPrefix
+}
+
+// OAuthAuthorizeURL is the value for LOLIA_OAUTH_AUTHORIZE_URL.
+func (s *Server) OAuthAuthorizeURL() string {
+	return s.URL() + "/oauth/authorize"
+}
+
+// OAuthTokenURL is the value for LOLIA_OAUTH_TOKEN_URL.
+func (s *Server) OAuthTokenURL() string {
+	return s.URL() + centerAPIPrefix + "/oauth2/token"
+}
+
+// OAuthDeviceAuthURL is the value for LOLIA_OAUTH_DEVICE_AUTH_URL.
+func (s *Server) OAuthDeviceAuthURL() string {
+	return s.URL() + centerAPIPrefix + "/oauth2/device_authorization"
+}
+
+// GitHubAPIBaseURL is the value for LOLIA_GITHUB_API_BASE_URL.
+func (s *Server) GitHubAPIBaseURL() string {
+	return s.URL() + "/github"
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /oauth/authorize", s.handleAuthorize)
+	mux.HandleFunc("POST "+centerAPIPrefix+"/oauth2/token", s.handleToken)
+	mux.HandleFunc("POST "+centerAPIPrefix+"/oauth2/device_authorization", s.handleDeviceAuthorization)
+	mux.HandleFunc("GET /oauth/device", s.handleDeviceVerify)
+
+	mux.HandleFunc("GET "+centerAPIPrefix+"/user/info", s.authorized(func(r *http.Request) (any, error) {
+		return s.data.userInfo(), nil
+	}))
+	mux.HandleFunc("GET "+centerAPIPrefix+"/user/traffic/stats", s.authorized(func(r *http.Request) (any, error) {
+		return s.data.trafficStats(), nil
+	}))
+	mux.HandleFunc("GET "+centerAPIPrefix+"/user/tunnel", s.authorized(func(r *http.Request) (any, error) {
+		return s.data.tunnelPage(queryInt(r, "page"), queryInt(r, "limit")), nil
+	}))
+	mux.HandleFunc("GET "+centerAPIPrefix+"/user/tunnel/{name}", s.authorized(func(r *http.Request) (any, error) {
+		tunnel, ok := s.data.tunnel(r.PathValue("name"))
+		if !ok {
+			return nil, errNotFound
+		}
+		return tunnel, nil
+	}))
+	mux.HandleFunc("POST "+centerAPIPrefix+"/user/tunnel", s.authorized(func(r *http.Request) (any, error) {
+		spec, err := decodeTunnelSpec(r)
+		if err != nil {
+			return nil, err
+		}
+		return s.data.createTunnel(spec)
+	}))
+	mux.HandleFunc("PUT "+centerAPIPrefix+"/user/tunnel/{name}", s.authorized(func(r *http.Request) (any, error) {
+		spec, err := decodeTunnelSpec(r)
+		if err != nil {
+			return nil, err
+		}
+		return s.data.updateTunnel(r.PathValue("name"), spec)
+	}))
+	mux.HandleFunc("DELETE "+centerAPIPrefix+"/user/tunnel/{name}", s.authorized(func(r *http.Request) (any, error) {
+		return nil, s.data.deleteTunnel(r.PathValue("name"))
+	}))
+	mux.HandleFunc("GET "+centerAPIPrefix+"/user/traffic/tunnels", s.authorized(func(r *http.Request) (any, error) {
+		return s.data.trafficTunnels(queryInt(r, "days")), nil
+	}))
+	mux.HandleFunc("GET "+centerAPIPrefix+"/user/traffic/daily", s.authorized(func(r *http.Request) (any, error) {
+		return s.data.recentDaily(queryInt(r, "days")), nil
+	}))
+	mux.HandleFunc("POST "+centerAPIPrefix+"/user/nodes", s.authorized(func(r *http.Request) (any, error) {
+		return s.data.nodeList(), nil
+	}))
+	mux.HandleFunc("GET "+centerAPIPrefix+"/user/frpc/config", s.authorized(func(r *http.Request) (any, error) {
+		config, ok := s.data.frpcConfig(r.URL.Query().Get("tunnel"))
+		if !ok {
+			return nil, errNotFound
+		}
+		return map[string]string{"config": config}, nil
+	}))
+	mux.HandleFunc("POST "+centerAPIPrefix+"/user/checkin", s.authorized(func(r *http.Request) (any, error) {
+		return s.data.checkIn(time.Now()), nil
+	}))
+	mux.HandleFunc("GET "+centerAPIPrefix+"/client/version", func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, http.StatusOK, map[string]string{"version": demoClientVersion})
+	})
+	mux.HandleFunc("GET "+centerAPIPrefix+"/home", func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, http.StatusOK, s.data.homeStats())
+	})
+
+	mux.HandleFunc("GET /github/repos/{owner}/{repo}/releases/latest", s.release.handleLatest)
+	mux.HandleFunc("GET /github/download/{asset}", s.release.handleDownload)
+	mux.HandleFunc("GET /github/rate_limit", s.release.handleRateLimit)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not found")
+	})
+	return mux
+}
+
+var errNotFound = errors.New("not found")
+
+// authorized wraps a Center API handler with the bearer token check and the
+// {code, msg, data} envelope.
+func (s *Server) authorized(handle func(r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(token) != demoAccessToken {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		data, err := handle(r)
+		if errors.Is(err, errNotFound) {
+			writeError(w, http.StatusNotFound, "资源不存在")
+			return
+		}
+		var invalid badRequest
+		if errors.As(err, &invalid) {
+			writeError(w, http.StatusBadRequest, invalid.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeEnvelope(w, http.StatusOK, data)
+	}
+}
+
+// handleAuthorize approves every request and redirects straight back to the client.
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	callback := redirectURI.Query()
+	callback.Set("code", "demo-code")
+	callback.Set("state", query.Get("state"))
+	redirectURI.RawQuery = callback.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// handleToken issues the demo token for any authorization_code or refresh_token grant
+// and for approved device codes.
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, "invalid_request")
+		return
+	}
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code", "refresh_token":
+	case deviceCodeGrant:
+		if !s.pollDeviceToken(w, r.PostForm.Get("device_code")) {
+			return
+		}
+	default:
+		writeOAuthError(w, "unsupported_grant_type")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token":  demoAccessToken,
+		"token_type":    "Bearer",
+		"refresh_token": demoRefreshToken,
+		"expires_in":    int(demoTokenTTL.Seconds()),
+		"scope":         "all",
+	})
+}
+
+func writeEnvelope(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"code": 200,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"code": status,
+		"msg":  message,
+		"data": nil,
+	})
+}
+
+func writeOAuthError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func queryInt(r *http.Request, key string) int {
+	value, err := strconv.Atoi(strings.TrimSpace(r.URL.Query().Get(key)))
+	if err != nil {
+		return 0
+	}
+	return value
+}
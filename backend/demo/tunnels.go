@@ -0,0 +1,134 @@
+package demo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"loliashizuku/backend/models"
+)
+
+// badRequest is reported to the client as HTTP 400 with the error text as message.
+type badRequest string
+
+func (e badRequest) Error() string { return string(e) }
+
+func decodeTunnelSpec(r *http.Request) (models.TunnelSpec, error) {
+	var spec models.TunnelSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		return spec, badRequest("请求体无效")
+	}
+	spec.Name = strings.TrimSpace(spec.Name)
+	spec.Type = strings.ToLower(strings.TrimSpace(spec.Type))
+	return spec, nil
+}
+
+// applySpecLocked validates spec and copies it onto tunnel.
+func (d *dataset) applySpecLocked(tunnel *models.TunnelDetailData, spec models.TunnelSpec) error {
+	if spec.Name == "" {
+		return badRequest("隧道名称不能为空")
+	}
+	switch spec.Type {
+	case "tcp", "udp", "http", "https":
+	default:
+		return badRequest("不支持的隧道类型：" + spec.Type)
+	}
+	if spec.NodeID <= 0 || int(spec.NodeID) > len(d.nodes) {
+		return badRequest("节点不存在")
+	}
+	if spec.LocalPort <= 0 || spec.LocalPort > 65535 {
+		return badRequest("本地端口无效")
+	}
+
+	node := d.nodes[spec.NodeID-1]
+	tunnel.Name = spec.Name
+	tunnel.Type = spec.Type
+	tunnel.NodeID = node.ID
+	tunnel.NodeName = node.Name
+	tunnel.NodeAddress = node.IPAddress
+	tunnel.LocalIP = spec.LocalIP
+	if tunnel.LocalIP == "" {
+		tunnel.LocalIP = "127.0.0.1"
+	}
+	tunnel.LocalPort = spec.LocalPort
+	tunnel.CustomDomain = strings.TrimSpace(spec.CustomDomain)
+	tunnel.Remark = strings.TrimSpace(spec.Remark)
+	switch {
+	case spec.Type == "http" || spec.Type == "https":
+		tunnel.RemotePort = 0
+	case spec.RemotePort > 0:
+		tunnel.RemotePort = spec.RemotePort
+	case tunnel.RemotePort == 0:
+		tunnel.RemotePort = int64(20000 + rand.IntN(40000))
+	}
+	return nil
+}
+
+func (d *dataset) indexOfTunnelLocked(name string) int {
+	for i, tunnel := range d.tunnels {
+		if tunnel.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d *dataset) createTunnel(spec models.TunnelSpec) (models.TunnelDetailData, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.indexOfTunnelLocked(spec.Name) >= 0 {
+		return models.TunnelDetailData{}, badRequest("隧道名称已存在")
+	}
+
+	var maxID int64
+	for _, tunnel := range d.tunnels {
+		maxID = max(maxID, tunnel.ID)
+	}
+	tunnel := models.TunnelDetailData{
+		BandwidthLimit: 10,
+		ClientVersion:  demoFrpsVersion,
+		CreatedAt:      time.Now().UTC().Format(time.RFC3339),
+		ID:             maxID + 1,
+		Status:         "inactive",
+	}
+	if err := d.applySpecLocked(&tunnel, spec); err != nil {
+		return models.TunnelDetailData{}, err
+	}
+	tunnel.TunnelToken = fmt.Sprintf("demo-%s-%04d", tunnel.Name, rand.IntN(10000))
+	d.tunnels = append(d.tunnels, tunnel)
+	return tunnel, nil
+}
+
+func (d *dataset) updateTunnel(name string, spec models.TunnelSpec) (models.TunnelDetailData, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	index := d.indexOfTunnelLocked(name)
+	if index < 0 {
+		return models.TunnelDetailData{}, errNotFound
+	}
+	if spec.Name != name && d.indexOfTunnelLocked(spec.Name) >= 0 {
+		return models.TunnelDetailData{}, badRequest("隧道名称已存在")
+	}
+
+	tunnel := d.tunnels[index]
+	if err := d.applySpecLocked(&tunnel, spec); err != nil {
+		return models.TunnelDetailData{}, err
+	}
+	d.tunnels[index] = tunnel
+	return tunnel, nil
+}
+
+func (d *dataset) deleteTunnel(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	index := d.indexOfTunnelLocked(name)
+	if index < 0 {
+		return errNotFound
+	}
+	d.tunnels = append(d.tunnels[:index], d.tunnels[index+1:]...)
+	delete(d.perTunnel, name)
+	return nil
+}
@@ -0,0 +1,289 @@
+// Package dnscheck verifies that a tunnel's custom domain resolves to its node.
+package dnscheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"loliashizuku/backend/models"
+)
+
+const defaultLookupTimeout = 5 * time.Second
+
+// Issue codes reported in models.DomainCheckIssue.
+const (
+	IssueNotFound        = "nxdomain"
+	IssueNoRecords       = "no_records"
+	IssueLookupFailed    = "lookup_failed"
+	IssueCNAMEMismatch   = "cname_mismatch"
+	IssueAddressMismatch = "address_mismatch"
+	IssuePartialMatch    = "partial_match"
+	IssueIPv6Only        = "ipv6_only"
+	IssueNodeUnresolved  = "node_unresolved"
+)
+
+// Resolver is the subset of *net.Resolver used by the verifier, so tests can
+// substitute a stand-in.
+type Resolver interface {
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+}
+
+// NewResolver returns a resolver that queries the DNS server at address (host:port)
+// instead of the system configuration. An empty address returns net.DefaultResolver.
+func NewResolver(address string) Resolver {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// Target describes a custom domain and the node it should point to.
+type Target struct {
+	Domain string
+	// NodeAddress is the node's IP address or hostname.
+	NodeAddress string
+}
+
+// Verifier checks custom domains against their node.
+type Verifier struct {
+	resolver Resolver
+	timeout  time.Duration
+}
+
+// NewVerifier creates a Verifier. A nil resolver uses net.DefaultResolver.
+func NewVerifier(resolver Resolver) *Verifier {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &Verifier{resolver: resolver, timeout: defaultLookupTimeout}
+}
+
+// Verify resolves the domain's CNAME, A and AAAA records and compares them to the node.
+// Only Domain, expected values, records, Status and Issues of the result are filled.
+func (v *Verifier) Verify(ctx context.Context, target Target) models.DomainCheckResult {
+	domain := normalizeHost(target.Domain)
+	result := models.DomainCheckResult{
+		Domain:      domain,
+		ExpectedIPs: []string{},
+		IPv4:        []string{},
+		IPv6:        []string{},
+		Issues:      []models.DomainCheckIssue{},
+		CheckedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+
+	expectedIPs, nodeIssue := v.expectedAddresses(ctx, target.NodeAddress, &result)
+	if nodeIssue != nil {
+		result.Issues = append(result.Issues, *nodeIssue)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	cname, err := v.resolver.LookupCNAME(ctx, domain)
+	if err != nil {
+		if issue := lookupIssue(domain, err); issue.Code == IssueNotFound {
+			result.Issues = append(result.Issues, issue)
+			result.Status = models.DomainCheckMismatch
+			return result
+		}
+	}
+	if cname = normalizeHost(cname); cname != "" && cname != domain {
+		result.CNAME = cname
+	}
+
+	ipv4, err4 := v.resolver.LookupIP(ctx, "ip4", domain)
+	ipv6, err6 := v.resolver.LookupIP(ctx, "ip6", domain)
+	result.IPv4 = ipStrings(ipv4)
+	result.IPv6 = ipStrings(ipv6)
+	if len(ipv4) == 0 && len(ipv6) == 0 {
+		err := err4
+		if err == nil {
+			err = err6
+		}
+		issue := lookupIssue(domain, err)
+		if issue.Code == IssueLookupFailed {
+			result.Issues = append(result.Issues, issue)
+			result.Status = models.DomainCheckError
+			return result
+		}
+		result.Issues = append(result.Issues, noRecordsIssue(domain, &result))
+		result.Status = models.DomainCheckMismatch
+		return result
+	}
+
+	if result.ExpectedHost != "" && result.CNAME != "" && result.CNAME != result.ExpectedHost {
+		result.Issues = append(result.Issues, models.DomainCheckIssue{
+			Code:    IssueCNAMEMismatch,
+			Message: fmt.Sprintf("CNAME 指向 %s，应指向节点地址 %s", result.CNAME, result.ExpectedHost),
+		})
+	}
+	if len(expectedIPs) > 0 {
+		result.Issues = append(result.Issues, compareAddresses(domain, expectedIPs, ipv4, ipv6)...)
+	}
+
+	switch {
+	case len(result.Issues) == 0:
+		result.Status = models.DomainCheckOK
+	case nodeIssue != nil && len(result.Issues) == 1:
+		// Only the CNAME could be checked; a matching CNAME is as good as it gets.
+		if result.ExpectedHost != "" && result.CNAME == result.ExpectedHost {
+			result.Status = models.DomainCheckOK
+		} else {
+			result.Status = models.DomainCheckError
+		}
+	default:
+		result.Status = models.DomainCheckMismatch
+	}
+	return result
+}
+
+// expectedAddresses returns the node's IPs, resolving the node hostname when needed.
+func (v *Verifier) expectedAddresses(ctx context.Context, nodeAddress string, result *models.DomainCheckResult) ([]net.IP, *models.DomainCheckIssue) {
+	address := normalizeHost(nodeAddress)
+	if address == "" {
+		return nil, &models.DomainCheckIssue{
+			Code:    IssueNodeUnresolved,
+			Message: "节点未提供地址，无法比对解析结果",
+		}
+	}
+	if ip := net.ParseIP(address); ip != nil {
+		result.ExpectedIPs = []string{ip.String()}
+		return []net.IP{ip}, nil
+	}
+
+	result.ExpectedHost = address
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+	ips, err := v.resolver.LookupIP(ctx, "ip", address)
+	if err != nil || len(ips) == 0 {
+		return nil, &models.DomainCheckIssue{
+			Code:    IssueNodeUnresolved,
+			Message: fmt.Sprintf("无法解析节点地址 %s，只能检查 CNAME", address),
+		}
+	}
+	result.ExpectedIPs = ipStrings(ips)
+	return ips, nil
+}
+
+func compareAddresses(domain string, expected, ipv4, ipv6 []net.IP) []models.DomainCheckIssue {
+	expectedSet := map[string]struct{}{}
+	expectsIPv6 := false
+	for _, ip := range expected {
+		expectedSet[ip.String()] = struct{}{}
+		if ip.To4() == nil {
+			expectsIPv6 = true
+		}
+	}
+
+	var matched, unmatched []string
+	for _, ip := range append(append([]net.IP{}, ipv4...), ipv6...) {
+		if ip.To4() == nil && !expectsIPv6 {
+			// The node has no IPv6 address to compare against; judged separately below.
+			continue
+		}
+		if _, ok := expectedSet[ip.String()]; ok {
+			matched = append(matched, ip.String())
+		} else {
+			unmatched = append(unmatched, ip.String())
+		}
+	}
+
+	var issues []models.DomainCheckIssue
+	switch {
+	case len(matched) == 0 && len(unmatched) > 0:
+		issues = append(issues, models.DomainCheckIssue{
+			Code: IssueAddressMismatch,
+			Message: fmt.Sprintf(
+				"%s 解析到 %s，但节点地址为 %s；如启用了 CDN 代理，请关闭代理（仅 DNS）",
+				domain, strings.Join(unmatched, ", "), strings.Join(ipStrings(expected), ", "),
+			),
+		})
+	case len(matched) > 0 && len(unmatched) > 0:
+		issues = append(issues, models.DomainCheckIssue{
+			Code:    IssuePartialMatch,
+			Message: fmt.Sprintf("%s 还存在不指向节点的记录 %s，部分访问可能失败，请删除多余记录", domain, strings.Join(unmatched, ", ")),
+		})
+	}
+
+	if len(ipv4) == 0 && len(ipv6) > 0 && !expectsIPv6 {
+		issues = append(issues, models.DomainCheckIssue{
+			Code:    IssueIPv6Only,
+			Message: fmt.Sprintf("%s 只有 AAAA 记录，但节点仅支持 IPv4，请添加指向 %s 的 A 记录", domain, strings.Join(ipStrings(expected), ", ")),
+		})
+	} else if len(ipv6) > 0 && !expectsIPv6 {
+		issues = append(issues, models.DomainCheckIssue{
+			Code:    IssuePartialMatch,
+			Message: fmt.Sprintf("%s 存在 AAAA 记录 %s，但节点不支持 IPv6，IPv6 访问将失败，请删除 AAAA 记录", domain, strings.Join(ipStrings(ipv6), ", ")),
+		})
+	}
+	return issues
+}
+
+func noRecordsIssue(domain string, result *models.DomainCheckResult) models.DomainCheckIssue {
+	hint := "请添加解析记录"
+	switch {
+	case result.ExpectedHost != "":
+		hint = fmt.Sprintf("请添加 CNAME 记录指向 %s", result.ExpectedHost)
+	case len(result.ExpectedIPs) > 0:
+		hint = fmt.Sprintf("请添加 A 记录指向 %s", strings.Join(result.ExpectedIPs, ", "))
+	}
+	return models.DomainCheckIssue{
+		Code:    IssueNoRecords,
+		Message: fmt.Sprintf("%s 没有 A/AAAA 记录，%s", domain, hint),
+	}
+}
+
+func lookupIssue(domain string, err error) models.DomainCheckIssue {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		if dnsErr.Err == "no such host" {
+			return models.DomainCheckIssue{
+				Code:    IssueNotFound,
+				Message: fmt.Sprintf("域名 %s 不存在，请检查拼写，或等待新添加的记录生效", domain),
+			}
+		}
+		return models.DomainCheckIssue{Code: IssueNoRecords}
+	}
+	if err == nil {
+		return models.DomainCheckIssue{Code: IssueNoRecords}
+	}
+	return models.DomainCheckIssue{
+		Code:    IssueLookupFailed,
+		Message: fmt.Sprintf("查询 %s 的 DNS 记录失败：%v", domain, err),
+	}
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+func ipStrings(ips []net.IP) []string {
+	values := make([]string, 0, len(ips))
+	seen := map[string]struct{}{}
+	for _, ip := range ips {
+		value := ip.String()
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,137 @@
+package dnscheck
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"loliashizuku/backend/models"
+)
+
+// fakeResolver answers from fixed records. Hosts in block wait for the context to end,
+// like a DNS server that never replies.
+type fakeResolver struct {
+	cnames map[string]string
+	ips    map[string][]string
+	block  map[string]bool
+}
+
+func (r *fakeResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	if r.block[host] {
+		<-ctx.Done()
+		return "", &net.DNSError{Err: ctx.Err().Error(), Name: host, IsTimeout: true}
+	}
+	if cname, ok := r.cnames[host]; ok {
+		return cname, nil
+	}
+	if _, ok := r.ips[host]; ok {
+		return host + ".", nil
+	}
+	return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	if r.block[host] {
+		<-ctx.Done()
+		return nil, &net.DNSError{Err: ctx.Err().Error(), Name: host, IsTimeout: true}
+	}
+	target := host
+	if cname, ok := r.cnames[host]; ok {
+		target = normalizeHost(cname)
+	}
+	values, ok := r.ips[target]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	var ips []net.IP
+	for _, value := range values {
+		ip := net.ParseIP(value)
+		isV4 := ip.To4() != nil
+		if (network == "ip4" && !isV4) || (network == "ip6" && isV4) {
+			continue
+		}
+		ips = append(ips, ip)
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return ips, nil
+}
+
+func TestVerify(t *testing.T) {
+	resolver := &fakeResolver{
+		cnames: map[string]string{
+			"good.example.com":  "node1.example.net.",
+			"wrong.example.com": "cdn.example.org.",
+		},
+		ips: map[string][]string{
+			"node1.example.net": {"203.0.113.10"},
+			"cdn.example.org":   {"198.51.100.7"},
+		},
+		block: map[string]bool{"slow.example.com": true},
+	}
+
+	tests := []struct {
+		name       string
+		target     Target
+		wantStatus string
+		wantIssues []string
+		wantCNAME  string
+	}{
+		{
+			name:       "cname matches node",
+			target:     Target{Domain: "good.example.com", NodeAddress: "node1.example.net"},
+			wantStatus: models.DomainCheckOK,
+			wantCNAME:  "node1.example.net",
+		},
+		{
+			name:       "cname points elsewhere",
+			target:     Target{Domain: "wrong.example.com", NodeAddress: "node1.example.net"},
+			wantStatus: models.DomainCheckMismatch,
+			wantIssues: []string{IssueCNAMEMismatch, IssueAddressMismatch},
+			wantCNAME:  "cdn.example.org",
+		},
+		{
+			name:       "nxdomain",
+			target:     Target{Domain: "missing.example.com", NodeAddress: "203.0.113.10"},
+			wantStatus: models.DomainCheckMismatch,
+			wantIssues: []string{IssueNotFound},
+		},
+		{
+			name:       "resolver timeout",
+			target:     Target{Domain: "slow.example.com", NodeAddress: "203.0.113.10"},
+			wantStatus: models.DomainCheckError,
+			wantIssues: []string{IssueLookupFailed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewVerifier(resolver)
+			verifier.timeout = 50 * time.Millisecond
+
+			result := verifier.Verify(context.Background(), tt.target)
+			if result.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q (issues %+v)", result.Status, tt.wantStatus, result.Issues)
+			}
+			if result.CNAME != tt.wantCNAME {
+				t.Errorf("cname = %q, want %q", result.CNAME, tt.wantCNAME)
+			}
+			codes := make([]string, 0, len(result.Issues))
+			for _, issue := range result.Issues {
+				codes = append(codes, issue.Code)
+			}
+			if len(codes) != len(tt.wantIssues) {
+				t.Fatalf("issues = %v, want %v", codes, tt.wantIssues)
+			}
+			for i := range codes {
+				if codes[i] != tt.wantIssues[i] {
+					t.Errorf("issues = %v, want %v", codes, tt.wantIssues)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+// Package frpcconf renders standalone frpc TOML configuration files from tunnel details.
+package frpcconf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"loliashizuku/backend/models"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Tunnel is a tunnel together with the node it runs on.
+type Tunnel struct {
+	Detail models.TunnelDetailData
+	Node   models.NodeItem
+}
+
+// Options controls how tunnels are turned into files.
+type Options struct {
+	// Group puts tunnels that share a node into one file. frpc connects to a single
+	// server per config, so tunnels on different nodes always end up in separate files.
+	Group bool
+	// AccountToken authenticates a grouped file whose tunnels carry different tokens.
+	AccountToken string
+	// ProxyURL, when set, returns the proxy frpc dials the given server through.
+	ProxyURL func(serverAddr string, serverPort int64) string
+}
+
+// File is one generated frpc configuration.
+type File struct {
+	Name       string
+	NodeID     int64
+	NodeName   string
+	ServerAddr string
+	ServerPort int64
+	Tunnels    []string
+	Content    []byte
+}
+
+type clientConfig struct {
+	ServerAddr string        `toml:"serverAddr"`
+	ServerPort int64         `toml:"serverPort"`
+	Auth       authConfig    `toml:"auth"`
+	Transport  *transport    `toml:"transport,omitempty"`
+	Proxies    []proxyConfig `toml:"proxies"`
+}
+
+type authConfig struct {
+	Method string `toml:"method"`
+	Token  string `toml:"token"`
+}
+
+type transport struct {
+	ProxyURL string `toml:"proxyURL"`
+}
+
+type proxyConfig struct {
+	Name          string   `toml:"name"`
+	Type          string   `toml:"type"`
+	LocalIP       string   `toml:"localIP"`
+	LocalPort     int64    `toml:"localPort"`
+	RemotePort    int64    `toml:"remotePort,omitzero"`
+	CustomDomains []string `toml:"customDomains,omitempty"`
+}
+
+// Generate renders one file per tunnel, or per node when opts.Group is set.
+func Generate(tunnels []Tunnel, opts Options) ([]File, error) {
+	if len(tunnels) == 0 {
+		return nil, fmt.Errorf("no tunnels to generate")
+	}
+
+	var groups [][]Tunnel
+	if opts.Group {
+		index := map[int64]int{}
+		for _, tunnel := range tunnels {
+			i, ok := index[tunnel.Detail.NodeID]
+			if !ok {
+				i = len(groups)
+				index[tunnel.Detail.NodeID] = i
+				groups = append(groups, nil)
+			}
+			groups[i] = append(groups[i], tunnel)
+		}
+	} else {
+		for _, tunnel := range tunnels {
+			groups = append(groups, []Tunnel{tunnel})
+		}
+	}
+
+	files := make([]File, 0, len(groups))
+	for _, group := range groups {
+		file, err := render(group, opts)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func render(group []Tunnel, opts Options) (File, error) {
+	first := group[0]
+	serverAddr := strings.TrimSpace(first.Node.IPAddress)
+	if serverAddr == "" {
+		serverAddr = strings.TrimSpace(first.Detail.NodeAddress)
+	}
+	if serverAddr == "" {
+		return File{}, fmt.Errorf("tunnel %q: node address is unknown", first.Detail.Name)
+	}
+	if first.Node.FrpsPort <= 0 {
+		return File{}, fmt.Errorf("tunnel %q: node frps port is unknown", first.Detail.Name)
+	}
+
+	token, err := groupToken(group, opts.AccountToken)
+	if err != nil {
+		return File{}, err
+	}
+
+	config := clientConfig{
+		ServerAddr: serverAddr,
+		ServerPort: first.Node.FrpsPort,
+		Auth:       authConfig{Method: "token", Token: token},
+	}
+	if opts.ProxyURL != nil {
+		if proxyURL := opts.ProxyURL(serverAddr, first.Node.FrpsPort); proxyURL != "" {
+			config.Transport = &transport{ProxyURL: proxyURL}
+		}
+	}
+	names := make([]string, 0, len(group))
+	for _, tunnel := range group {
+		proxy, err := proxySection(tunnel.Detail)
+		if err != nil {
+			return File{}, err
+		}
+		config.Proxies = append(config.Proxies, proxy)
+		names = append(names, proxy.Name)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# frpc configuration for %s\n", strings.Join(names, ", "))
+	fmt.Fprintf(&buf, "# Node: %s\n\n", nodeLabel(first))
+	encoder := toml.NewEncoder(&buf)
+	encoder.Indent = ""
+	if err := encoder.Encode(config); err != nil {
+		return File{}, fmt.Errorf("encode frpc config: %w", err)
+	}
+
+	name := names[0]
+	if len(names) > 1 {
+		name = "node-" + fmt.Sprint(first.Detail.NodeID)
+	}
+	return File{
+		Name:       sanitizeFileName(name) + ".toml",
+		NodeID:     first.Detail.NodeID,
+		NodeName:   first.Node.Name,
+		ServerAddr: serverAddr,
+		ServerPort: first.Node.FrpsPort,
+		Tunnels:    names,
+		Content:    buf.Bytes(),
+	}, nil
+}
+
+// groupToken returns the token shared by every tunnel of the group, or the account
+// token when the tunnels' tokens differ. A tunnel authenticates as "<id>:<token>",
+// the same value the runner passes to frpc with -t.
+func groupToken(group []Tunnel, accountToken string) (string, error) {
+	tokens := map[string]struct{}{}
+	for _, tunnel := range group {
+		token := strings.TrimSpace(tunnel.Detail.TunnelToken)
+		if token == "" {
+			return "", fmt.Errorf("tunnel %q: tunnel token is missing", tunnel.Detail.Name)
+		}
+		if tunnel.Detail.ID <= 0 {
+			return "", fmt.Errorf("tunnel %q: tunnel id is missing", tunnel.Detail.Name)
+		}
+		tokens[fmt.Sprintf("%d:%s", tunnel.Detail.ID, token)] = struct{}{}
+	}
+	if len(tokens) == 1 {
+		for token := range tokens {
+			return token, nil
+		}
+	}
+	if token := strings.TrimSpace(accountToken); token != "" {
+		return token, nil
+	}
+	return "", fmt.Errorf("tunnels on node %d use different tokens and no account token is available", group[0].Detail.NodeID)
+}
+
+func proxySection(detail models.TunnelDetailData) (proxyConfig, error) {
+	proxy := proxyConfig{
+		Name:      strings.TrimSpace(detail.Name),
+		Type:      strings.ToLower(strings.TrimSpace(detail.Type)),
+		LocalIP:   strings.TrimSpace(detail.LocalIP),
+		LocalPort: detail.LocalPort,
+	}
+	if proxy.LocalIP == "" {
+		proxy.LocalIP = "127.0.0.1"
+	}
+	if proxy.LocalPort <= 0 {
+		return proxyConfig{}, fmt.Errorf("tunnel %q: local port is missing", proxy.Name)
+	}
+
+	switch proxy.Type {
+	case "tcp", "udp":
+		if detail.RemotePort <= 0 {
+			return proxyConfig{}, fmt.Errorf("tunnel %q: remote port is missing", proxy.Name)
+		}
+		proxy.RemotePort = detail.RemotePort
+	case "http", "https":
+		proxy.CustomDomains = splitDomains(detail.CustomDomain)
+		if len(proxy.CustomDomains) == 0 {
+			return proxyConfig{}, fmt.Errorf("tunnel %q: custom domain is missing", proxy.Name)
+		}
+	default:
+		return proxyConfig{}, fmt.Errorf("tunnel %q: unsupported type %q", proxy.Name, detail.Type)
+	}
+	return proxy, nil
+}
+
+func splitDomains(raw string) []string {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\n' || r == '\t'
+	})
+	seen := map[string]struct{}{}
+	domains := make([]string, 0, len(fields))
+	for _, field := range fields {
+		domain := strings.ToLower(strings.TrimSpace(field))
+		if _, ok := seen[domain]; ok || domain == "" {
+			continue
+		}
+		seen[domain] = struct{}{}
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+func nodeLabel(tunnel Tunnel) string {
+	name := strings.TrimSpace(tunnel.Node.Name)
+	if name == "" {
+		name = strings.TrimSpace(tunnel.Detail.NodeName)
+	}
+	if name == "" {
+		return fmt.Sprintf("#%d", tunnel.Detail.NodeID)
+	}
+	return fmt.Sprintf("%s (#%d)", name, tunnel.Detail.NodeID)
+}
+
+func sanitizeFileName(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if cleaned == "" {
+		return "frpc"
+	}
+	return cleaned
+}
@@ -0,0 +1,328 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"loliashizuku/backend/models"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	dateLayout  = "2006-01-02"
+	monthLayout = "2006-01"
+	keySep      = "|"
+)
+
+var (
+	bucketDailyTunnel  = []byte("daily_tunnel")
+	bucketTunnelTotals = []byte("tunnel_totals")
+	bucketUserTraffic  = []byte("user_traffic")
+)
+
+// Store keeps long-term traffic history in an embedded bbolt database.
+// Data is partitioned per user so several accounts can share one file.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens (or creates) the history database at path.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create history directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open history database: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the database file.
+func (s *Store) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// PutDailyTraffic upserts per-day, per-tunnel traffic. Entries are keyed by date and
+// tunnel name, so repeated snapshots overwrite instead of duplicating.
+func (s *Store) PutDailyTraffic(userID int64, daily *models.DailyTrafficResponse) (int, error) {
+	if daily == nil {
+		return 0, nil
+	}
+
+	updatedAt := time.Now().UTC().Format(time.RFC3339)
+	written := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, bucketDailyTunnel)
+		if err != nil {
+			return err
+		}
+		for _, stat := range daily.DailyStats {
+			date, ok := normalizeDate(stat.Date)
+			if !ok {
+				continue
+			}
+			for _, tunnel := range stat.TunnelStats {
+				name := strings.TrimSpace(tunnel.TunnelName)
+				if name == "" {
+					continue
+				}
+				entry := models.TrafficHistoryEntry{
+					Date:         date,
+					TunnelName:   name,
+					Remark:       strings.TrimSpace(tunnel.Remark),
+					TotalTraffic: tunnel.TotalTraffic,
+					UpdatedAt:    updatedAt,
+				}
+				if err := putJSON(bucket, []byte(date+keySep+name), entry); err != nil {
+					return err
+				}
+				written++
+			}
+		}
+		return nil
+	})
+	return written, err
+}
+
+// PutTunnelTotals stores the rolling per-tunnel totals captured on the given day.
+func (s *Store) PutTunnelTotals(userID int64, capturedOn time.Time, data *models.TrafficTunnelData) (int, error) {
+	if data == nil {
+		return 0, nil
+	}
+
+	snapshot := models.TunnelTotalsSnapshot{
+		CapturedOn: capturedOn.Format(dateLayout),
+		Days:       data.Days,
+		Tunnels:    data.Tunnels,
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, bucketTunnelTotals)
+		if err != nil {
+			return err
+		}
+		return putJSON(bucket, []byte(snapshot.CapturedOn), snapshot)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(data.Tunnels), nil
+}
+
+// PutUserTraffic stores the account traffic usage captured on the given day.
+func (s *Store) PutUserTraffic(userID int64, capturedOn time.Time, data *models.UserTrafficData) error {
+	if data == nil {
+		return nil
+	}
+
+	snapshot := models.UserTrafficSnapshot{
+		Date:    capturedOn.Format(dateLayout),
+		Traffic: *data,
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, bucketUserTraffic)
+		if err != nil {
+			return err
+		}
+		return putJSON(bucket, []byte(snapshot.Date), snapshot)
+	})
+}
+
+// DailyEntries returns every stored entry with startDate <= date <= endDate, ordered by date and tunnel.
+func (s *Store) DailyEntries(userID int64, startDate, endDate string) ([]models.TrafficHistoryEntry, error) {
+	entries := []models.TrafficHistoryEntry{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := existingUserBucket(tx, userID, bucketDailyTunnel)
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+		for key, value := cursor.Seek([]byte(startDate)); key != nil; key, value = cursor.Next() {
+			date, _, _ := bytes.Cut(key, []byte(keySep))
+			if endDate != "" && string(date) > endDate {
+				break
+			}
+			var entry models.TrafficHistoryEntry
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return fmt.Errorf("decode history entry %s: %w", key, err)
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// UserTrafficSnapshots returns stored account usage snapshots in date order.
+func (s *Store) UserTrafficSnapshots(userID int64, startDate, endDate string) ([]models.UserTrafficSnapshot, error) {
+	snapshots := []models.UserTrafficSnapshot{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := existingUserBucket(tx, userID, bucketUserTraffic)
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+		for key, value := cursor.Seek([]byte(startDate)); key != nil; key, value = cursor.Next() {
+			if endDate != "" && string(key) > endDate {
+				break
+			}
+			var snapshot models.UserTrafficSnapshot
+			if err := json.Unmarshal(value, &snapshot); err != nil {
+				return fmt.Errorf("decode user traffic snapshot %s: %w", key, err)
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+		return nil
+	})
+	return snapshots, err
+}
+
+// MonthlyTotals sums daily entries per calendar month. Months are "YYYY-MM"; empty bounds are open.
+func (s *Store) MonthlyTotals(userID int64, startMonth, endMonth string) ([]models.MonthlyTrafficTotal, error) {
+	startDate, endDate, err := monthRange(startMonth, endMonth)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.DailyEntries(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	byMonth := map[string]*models.MonthlyTrafficTotal{}
+	daysSeen := map[string]map[string]struct{}{}
+	for _, entry := range entries {
+		month := entry.Date[:len(monthLayout)]
+		total, ok := byMonth[month]
+		if !ok {
+			total = &models.MonthlyTrafficTotal{Month: month, Tunnels: map[string]int64{}}
+			byMonth[month] = total
+			daysSeen[month] = map[string]struct{}{}
+		}
+		total.TotalTraffic += entry.TotalTraffic
+		total.Tunnels[entry.TunnelName] += entry.TotalTraffic
+		daysSeen[month][entry.Date] = struct{}{}
+	}
+
+	totals := make([]models.MonthlyTrafficTotal, 0, len(byMonth))
+	for month, total := range byMonth {
+		total.Days = len(daysSeen[month])
+		totals = append(totals, *total)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Month < totals[j].Month })
+	return totals, nil
+}
+
+// TunnelHistory returns the per-day traffic of each tunnel in the range.
+// An empty tunnelName returns every tunnel.
+func (s *Store) TunnelHistory(userID int64, tunnelName, startDate, endDate string) ([]models.TunnelTrafficHistory, error) {
+	entries, err := s.DailyEntries(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(tunnelName)
+	byTunnel := map[string]*models.TunnelTrafficHistory{}
+	for _, entry := range entries {
+		if name != "" && entry.TunnelName != name {
+			continue
+		}
+		history, ok := byTunnel[entry.TunnelName]
+		if !ok {
+			history = &models.TunnelTrafficHistory{TunnelName: entry.TunnelName}
+			byTunnel[entry.TunnelName] = history
+		}
+		if entry.Remark != "" {
+			history.Remark = entry.Remark
+		}
+		history.TotalTraffic += entry.TotalTraffic
+		history.Points = append(history.Points, models.TunnelTrafficPoint{
+			Date:         entry.Date,
+			TotalTraffic: entry.TotalTraffic,
+		})
+	}
+
+	histories := make([]models.TunnelTrafficHistory, 0, len(byTunnel))
+	for _, history := range byTunnel {
+		histories = append(histories, *history)
+	}
+	sort.Slice(histories, func(i, j int) bool { return histories[i].TunnelName < histories[j].TunnelName })
+	return histories, nil
+}
+
+func userBucket(tx *bolt.Tx, userID int64, name []byte) (*bolt.Bucket, error) {
+	root, err := tx.CreateBucketIfNotExists(userBucketName(userID))
+	if err != nil {
+		return nil, fmt.Errorf("create user bucket: %w", err)
+	}
+	bucket, err := root.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, fmt.Errorf("create bucket %s: %w", name, err)
+	}
+	return bucket, nil
+}
+
+func existingUserBucket(tx *bolt.Tx, userID int64, name []byte) *bolt.Bucket {
+	root := tx.Bucket(userBucketName(userID))
+	if root == nil {
+		return nil
+	}
+	return root.Bucket(name)
+}
+
+func userBucketName(userID int64) []byte {
+	return []byte("user:" + strconv.FormatInt(userID, 10))
+}
+
+func putJSON(bucket *bolt.Bucket, key []byte, value any) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode history value: %w", err)
+	}
+	return bucket.Put(key, payload)
+}
+
+func normalizeDate(raw string) (string, bool) {
+	trimmed := strings.TrimSpace(raw)
+	if len(trimmed) < len(dateLayout) {
+		return "", false
+	}
+	date := trimmed[:len(dateLayout)]
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return "", false
+	}
+	return date, true
+}
+
+func monthRange(startMonth, endMonth string) (string, string, error) {
+	startDate := ""
+	if trimmed := strings.TrimSpace(startMonth); trimmed != "" {
+		start, err := time.Parse(monthLayout, trimmed)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid start month %q", startMonth)
+		}
+		startDate = start.Format(dateLayout)
+	}
+
+	endDate := ""
+	if trimmed := strings.TrimSpace(endMonth); trimmed != "" {
+		end, err := time.Parse(monthLayout, trimmed)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid end month %q", endMonth)
+		}
+		endDate = end.AddDate(0, 1, -1).Format(dateLayout)
+	}
+	return startDate, endDate, nil
+}
@@ -34,14 +34,18 @@ type Options struct {
 	UserAgent      string
 	GetAccessToken func(ctx context.Context) (string, error)
 	OnUnauthorized func(ctx context.Context) error
+	// Retry enables retries of transient failures. Nil disables retrying.
+	Retry *RetryPolicy
+	// OnAttempt is called after every attempt, including the last one.
+	OnAttempt func(ctx context.Context, attempt Attempt)
+	// Middlewares wrap every request, outermost first, around the built-in chain
+	// (ClientMiddlewares) derived from the options above.
+	Middlewares []Middleware
 }
 
 type Client struct {
-	baseURL        string
-	httpClient     *http.Client
-	userAgent      string
-	getAccessToken func(ctx context.Context) (string, error)
-	onUnauthorized func(ctx context.Context) error
+	baseURL    string
+	httpClient *http.Client
 }
 
 type envelopeProbe struct {
@@ -63,19 +67,36 @@ func ResolveUserAgent(userAgent string) string {
 }
 
 func New(options Options) *Client {
-	userAgent := ResolveUserAgent(options.UserAgent)
-	httpClient := options.HTTPClient
-	if httpClient == nil {
-		httpClient = &http.Client{}
+	middlewares := append(append([]Middleware{}, options.Middlewares...), ClientMiddlewares(options)...)
+	return &Client{
+		baseURL:    strings.TrimRight(strings.TrimSpace(options.BaseURL), "/"),
+		httpClient: WrapClient(options.HTTPClient, middlewares...),
 	}
+}
 
-	return &Client{
-		baseURL:        strings.TrimRight(strings.TrimSpace(options.BaseURL), "/"),
-		httpClient:     httpClient,
-		userAgent:      userAgent,
-		getAccessToken: options.GetAccessToken,
-		onUnauthorized: options.OnUnauthorized,
+// ClientMiddlewares returns the chain a Client builds from options: the unauthorized
+// callback, default headers, bearer auth and, innermost, retries.
+func ClientMiddlewares(options Options) []Middleware {
+	middlewares := []Middleware{
+		Headers(map[string]string{
+			"User-Agent": ResolveUserAgent(options.UserAgent),
+			"Accept":     "application/json",
+		}),
 	}
+	if options.OnUnauthorized != nil {
+		middlewares = append([]Middleware{Unauthorized(options.OnUnauthorized)}, middlewares...)
+	}
+	if options.GetAccessToken != nil {
+		middlewares = append(middlewares, BearerAuth(options.GetAccessToken))
+	}
+	if options.Retry != nil || options.OnAttempt != nil {
+		retry := RetryPolicy{MaxAttempts: 1}
+		if options.Retry != nil {
+			retry = *options.Retry
+		}
+		middlewares = append(middlewares, Retry(retry, options.OnAttempt))
+	}
+	return middlewares
 }
 
 func (c *Client) DoJSON(
@@ -98,42 +119,17 @@ func (c *Client) DoJSON(
 	}
 	requestURL.RawQuery = queryValues.Encode()
 
-	var reqBody io.Reader
-	if body != nil {
-		payload, marshalErr := json.Marshal(body)
-		if marshalErr != nil {
-			return fmt.Errorf("marshal request body for %s: %w", path, marshalErr)
-		}
-		reqBody = bytes.NewReader(payload)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, method, requestURL.String(), reqBody)
-	if err != nil {
-		return fmt.Errorf("build request for %s: %w", path, err)
-	}
-	req.Header.Set("User-Agent", c.userAgent)
-	req.Header.Set("Accept", "application/json")
+	var payloadBody []byte
 	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-
-	if c.getAccessToken != nil {
-		accessToken, tokenErr := c.getAccessToken(ctx)
-		if tokenErr != nil {
-			return tokenErr
+		payloadBody, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request body for %s: %w", path, err)
 		}
-		req.Header.Set("Authorization", "Bearer "+accessToken)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, payload, err := c.send(WithRequestPath(ctx, path), method, path, requestURL.String(), payloadBody)
 	if err != nil {
-		return fmt.Errorf("request %s %s: %w", method, path, err)
-	}
-	defer resp.Body.Close()
-
-	payload, readErr := io.ReadAll(resp.Body)
-	if readErr != nil {
-		return fmt.Errorf("read response body for %s: %w", path, readErr)
+		return err
 	}
 
 	var probe envelopeProbe
@@ -161,11 +157,8 @@ func (c *Client) DoJSON(
 		message = strings.TrimSpace(string(payload))
 	}
 
-	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden ||
-		businessCode == http.StatusUnauthorized || businessCode == http.StatusForbidden {
-		if c.onUnauthorized != nil {
-			_ = c.onUnauthorized(ctx)
-		}
+	// The Unauthorized middleware has already run the callback for these responses.
+	if isUnauthorizedStatus(resp.StatusCode) || isUnauthorizedStatus(businessCode) {
 		apiErr := &APIError{
 			Path:       path,
 			StatusCode: resp.StatusCode,
@@ -217,6 +210,41 @@ func (c *Client) DoJSON(
 	return nil
 }
 
+func (c *Client) send(
+	ctx context.Context,
+	method, path, requestURL string,
+	payloadBody []byte,
+) (*http.Response, []byte, error) {
+	var reqBody io.Reader
+	if payloadBody != nil {
+		reqBody = bytes.NewReader(payloadBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reqBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("build request for %s: %w", path, err)
+	}
+	if payloadBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		var authErr *authError
+		if errors.As(err, &authErr) {
+			return nil, nil, authErr.err
+		}
+		return nil, nil, &transportError{err: fmt.Errorf("request %s %s: %w", method, path, err)}
+	}
+	defer resp.Body.Close()
+
+	payload, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		return resp, nil, fmt.Errorf("read response body for %s: %w", path, readErr)
+	}
+	return resp, payload, nil
+}
+
 func hasEnvelopeShape(payload []byte) bool {
 	var root map[string]json.RawMessage
 	if err := json.Unmarshal(payload, &root); err != nil {
@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxETagBodyBytes skips caching responses too large to keep in memory.
+const maxETagBodyBytes = 4 << 20
+
+type etagEntry struct {
+	ETag     string      `json:"etag"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// ETagStore keeps the last response of each URL together with its ETag, optionally
+// persisted to a JSON snapshot so conditional requests survive restarts.
+type ETagStore struct {
+	mu           sync.Mutex
+	entries      map[string]*etagEntry
+	snapshotPath string
+	snapshotMu   sync.Mutex
+}
+
+// NewETagStore creates a store. When snapshotPath is not empty, saved entries are
+// loaded from it and every update is persisted back.
+func NewETagStore(snapshotPath string) *ETagStore {
+	store := &ETagStore{
+		entries:      map[string]*etagEntry{},
+		snapshotPath: strings.TrimSpace(snapshotPath),
+	}
+	store.loadSnapshot()
+	return store
+}
+
+// Clear drops every entry.
+func (s *ETagStore) Clear() {
+	s.mu.Lock()
+	s.entries = map[string]*etagEntry{}
+	s.mu.Unlock()
+	s.saveSnapshot()
+}
+
+func (s *ETagStore) get(key string) (*etagEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	return entry, ok
+}
+
+func (s *ETagStore) set(key string, entry *etagEntry) {
+	s.mu.Lock()
+	s.entries[key] = entry
+	s.mu.Unlock()
+	s.saveSnapshot()
+}
+
+func (s *ETagStore) loadSnapshot() {
+	if s.snapshotPath == "" {
+		return
+	}
+	raw, err := os.ReadFile(s.snapshotPath)
+	if err != nil {
+		return
+	}
+	var entries map[string]*etagEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return
+	}
+	for key, entry := range entries {
+		if entry != nil && entry.ETag != "" {
+			s.entries[key] = entry
+		}
+	}
+}
+
+func (s *ETagStore) saveSnapshot() {
+	if s.snapshotPath == "" {
+		return
+	}
+
+	s.mu.Lock()
+	payload, err := json.Marshal(s.entries)
+	s.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.snapshotPath), 0o755); err != nil {
+		return
+	}
+	tempPath := s.snapshotPath + ".tmp"
+	if err := os.WriteFile(tempPath, payload, 0o600); err != nil {
+		return
+	}
+	if err := os.Rename(tempPath, s.snapshotPath); err != nil {
+		_ = os.Remove(tempPath)
+	}
+}
+
+// ETagCache makes GET requests conditional on the ETag of the last stored response.
+// A 304 is answered from the store as a 200 whose headers are refreshed from the 304,
+// so callers see current rate-limit headers without handling revalidation themselves.
+func ETagCache(store *ETagStore) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if store == nil || req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" {
+				return next.RoundTrip(req)
+			}
+
+			key := req.URL.String()
+			cached, ok := store.get(key)
+			if ok {
+				req = req.Clone(req.Context())
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return resp, err
+			}
+
+			switch {
+			case resp.StatusCode == http.StatusNotModified && ok:
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				return cachedResponse(req, resp, cached), nil
+			case resp.StatusCode == http.StatusOK:
+				etag := resp.Header.Get("ETag")
+				if etag == "" || resp.ContentLength > maxETagBodyBytes {
+					return resp, nil
+				}
+				body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxETagBodyBytes+1))
+				resp.Body.Close()
+				if readErr != nil {
+					return nil, readErr
+				}
+				resp.Body = io.NopCloser(bytes.NewReader(body))
+				if len(body) <= maxETagBodyBytes {
+					store.set(key, &etagEntry{
+						ETag:     etag,
+						Header:   resp.Header.Clone(),
+						Body:     body,
+						StoredAt: time.Now(),
+					})
+				}
+			}
+			return resp, nil
+		})
+	}
+}
+
+func cachedResponse(req *http.Request, notModified *http.Response, cached *etagEntry) *http.Response {
+	header := cached.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for key, values := range notModified.Header {
+		header[key] = values
+	}
+	header.Set("Content-Length", strconv.Itoa(len(cached.Body)))
+	header.Set("X-From-Cache", "1")
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+		ContentLength: int64(len(cached.Body)),
+		Request:       req,
+	}
+}
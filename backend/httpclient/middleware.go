@@ -0,0 +1,262 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Middleware wraps a RoundTripper with extra behaviour, e.g. headers, logging or retries.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain wraps base with middlewares. The first middleware is the outermost one and
+// sees the request first. A nil base uses http.DefaultTransport.
+func Chain(base http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if middlewares[i] != nil {
+			base = middlewares[i](base)
+		}
+	}
+	return base
+}
+
+// WrapClient returns a shallow copy of client whose transport runs through middlewares.
+// A nil client is treated as an empty one.
+func WrapClient(client *http.Client, middlewares ...Middleware) *http.Client {
+	wrapped := &http.Client{}
+	if client != nil {
+		*wrapped = *client
+	}
+	wrapped.Transport = Chain(wrapped.Transport, middlewares...)
+	return wrapped
+}
+
+type requestPathKey struct{}
+
+// WithRequestPath tags ctx with the logical API path of a request, which middlewares
+// report instead of the full URL.
+func WithRequestPath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, requestPathKey{}, path)
+}
+
+// RequestPath returns the logical API path of req, or its URL path when none was set.
+func RequestPath(req *http.Request) string {
+	if path, ok := req.Context().Value(requestPathKey{}).(string); ok && path != "" {
+		return path
+	}
+	return req.URL.Path
+}
+
+// Headers sets each header on requests that do not already carry it.
+func Headers(headers map[string]string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			missing := false
+			for key := range headers {
+				if req.Header.Get(key) == "" {
+					missing = true
+					break
+				}
+			}
+			if !missing {
+				return next.RoundTrip(req)
+			}
+
+			req = req.Clone(req.Context())
+			for key, value := range headers {
+				if req.Header.Get(key) == "" && value != "" {
+					req.Header.Set(key, value)
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// BearerAuth adds an Authorization header with the token returned by getToken.
+// Requests that already carry an Authorization header, or for which getToken returns
+// an empty token, are sent as they are.
+func BearerAuth(getToken func(ctx context.Context) (string, error)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if getToken == nil || req.Header.Get("Authorization") != "" {
+				return next.RoundTrip(req)
+			}
+			token, err := getToken(req.Context())
+			if err != nil {
+				return nil, &authError{err: err}
+			}
+			if token == "" {
+				return next.RoundTrip(req)
+			}
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", "Bearer "+token)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// Unauthorized calls onUnauthorized when a response is 401/403, or is a Center API
+// envelope whose business code is 401/403. The response is returned unchanged.
+func Unauthorized(onUnauthorized func(ctx context.Context) error) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			if err != nil || onUnauthorized == nil {
+				return resp, err
+			}
+
+			unauthorized := isUnauthorizedStatus(resp.StatusCode)
+			if !unauthorized {
+				payload, readErr := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				resp.Body = io.NopCloser(bytes.NewReader(payload))
+				if readErr != nil {
+					return resp, nil
+				}
+				unauthorized = isUnauthorizedStatus(envelopeCode(payload))
+			}
+			if unauthorized {
+				_ = onUnauthorized(req.Context())
+			}
+			return resp, nil
+		})
+	}
+}
+
+// Observe calls observe after every round trip, e.g. for logging or metrics.
+func Observe(observe func(req *http.Request, resp *http.Response, err error, duration time.Duration)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			startedAt := time.Now()
+			resp, err := next.RoundTrip(req)
+			if observe != nil {
+				observe(req, resp, err, time.Since(startedAt))
+			}
+			return resp, err
+		})
+	}
+}
+
+// Retry retries transient failures according to policy. onAttempt, when set, is
+// called after every attempt. Requests whose body cannot be replayed are sent once.
+func Retry(policy RetryPolicy, onAttempt func(ctx context.Context, attempt Attempt)) Middleware {
+	policy = policy.normalized()
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+			for attempt := 1; ; attempt++ {
+				current := req
+				if attempt > 1 && req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, fmt.Errorf("replay request body: %w", err)
+					}
+					current = req.Clone(ctx)
+					current.Body = body
+				}
+
+				startedAt := time.Now()
+				resp, err := next.RoundTrip(current)
+				if err != nil {
+					err = &transportError{err: err}
+				}
+
+				info := Attempt{
+					Method:   req.Method,
+					Path:     RequestPath(req),
+					Attempt:  attempt,
+					Duration: time.Since(startedAt),
+					Err:      err,
+				}
+				if resp != nil {
+					info.StatusCode = resp.StatusCode
+				}
+
+				retryable := replayable &&
+					attempt < policy.MaxAttempts &&
+					policy.allowsMethod(req.Method) &&
+					policy.isRetryable(ctx, resp, err)
+				if retryable {
+					info.Delay, retryable = policy.backoff(attempt, resp)
+				}
+				if retryable {
+					if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < info.Delay {
+						retryable = false
+						info.Delay = 0
+					}
+				}
+				info.WillRetry = retryable
+				if onAttempt != nil {
+					onAttempt(ctx, info)
+				}
+
+				if !retryable {
+					return resp, err
+				}
+				if resp != nil {
+					_, _ = io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+
+				timer := time.NewTimer(info.Delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					if err == nil {
+						err = ctx.Err()
+					}
+					return nil, err
+				case <-timer.C:
+				}
+			}
+		})
+	}
+}
+
+// authError marks a failure to obtain an access token, which is not worth retrying.
+type authError struct {
+	err error
+}
+
+func (e *authError) Error() string {
+	return e.err.Error()
+}
+
+func (e *authError) Unwrap() error {
+	return e.err
+}
+
+func isUnauthorizedStatus(code int) bool {
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+func envelopeCode(payload []byte) int {
+	if len(payload) == 0 || !hasEnvelopeShape(payload) {
+		return 0
+	}
+	var probe envelopeProbe
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return 0
+	}
+	if probe.Code != 0 {
+		return probe.Code
+	}
+	return probe.Status
+}
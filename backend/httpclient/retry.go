@@ -0,0 +1,165 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures how Client retries transient failures.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt; it doubles on every retry.
+	BaseDelay time.Duration
+	// MaxDelay caps a single computed backoff.
+	MaxDelay time.Duration
+	// MaxRetryAfter is the longest Retry-After the client will wait for; longer requests give up.
+	MaxRetryAfter time.Duration
+	// Methods lists the HTTP methods that may be retried. Empty means idempotent methods only.
+	Methods []string
+	// StatusCodes lists the response status codes treated as transient.
+	StatusCodes []int
+}
+
+// Attempt describes a single request attempt reported to Options.OnAttempt.
+type Attempt struct {
+	Method     string
+	Path       string
+	Attempt    int
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+	WillRetry  bool
+	Delay      time.Duration
+}
+
+// DefaultRetryPolicy retries idempotent requests up to three times on connection
+// errors and 429/502/503/504 responses.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:   3,
+		BaseDelay:     300 * time.Millisecond,
+		MaxDelay:      5 * time.Second,
+		MaxRetryAfter: 30 * time.Second,
+		StatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+func (p RetryPolicy) normalized() RetryPolicy {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 300 * time.Millisecond
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+	if p.MaxRetryAfter <= 0 {
+		p.MaxRetryAfter = p.MaxDelay
+	}
+	if len(p.Methods) == 0 {
+		p.Methods = []string{
+			http.MethodGet,
+			http.MethodHead,
+			http.MethodOptions,
+			http.MethodPut,
+			http.MethodDelete,
+		}
+	}
+	return p
+}
+
+func (p RetryPolicy) allowsMethod(method string) bool {
+	for _, allowed := range p.Methods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RetryPolicy) isRetryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		// Only transport failures are retried; token and body read errors are not transient.
+		var transportErr *transportError
+		var authErr *authError
+		return errors.As(err, &transportErr) &&
+			!errors.As(err, &authErr) &&
+			!errors.Is(err, context.Canceled) &&
+			!errors.Is(err, context.DeadlineExceeded)
+	}
+	if resp == nil {
+		return false
+	}
+	for _, code := range p.StatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the next attempt: the server's Retry-After when
+// present, otherwise exponential backoff with full jitter. It reports false when the
+// server asks for a longer wait than MaxRetryAfter.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return delay, delay <= p.MaxRetryAfter
+		}
+	}
+
+	ceiling := p.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1)), true
+}
+
+// transportError marks failures where no HTTP response was received.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(trimmed); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(trimmed); err == nil {
+		delay := time.Until(at)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
@@ -0,0 +1,25 @@
+package models
+
+// Account is a signed-in LoliaFRP account known to the client. Tokens are kept in the
+// OS keyring; this is the non-secret metadata shown in the account switcher.
+type Account struct {
+	ID         int64  `json:"id"`
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+	Avatar     string `json:"avatar,omitempty"`
+	Alias      string `json:"alias,omitempty"`
+	AddedAt    string `json:"added_at"`
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	// SignedIn is false once the account's token was cleared, e.g. after logout or
+	// an unauthorized response; switching to it requires logging in again.
+	SignedIn bool `json:"signed_in"`
+	Active   bool `json:"active"`
+}
+
+// DisplayName returns the alias when set, otherwise the username.
+func (a Account) DisplayName() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+	return a.Username
+}
@@ -10,6 +10,15 @@ type APIEnvelope struct {
 	Data   json.RawMessage `json:"data"`
 }
 
+// Dashboard section keys used in CenterDashboardData.Errors.
+const (
+	DashboardSectionUser    = "user"
+	DashboardSectionTraffic = "traffic"
+	DashboardSectionTunnels = "tunnels"
+	DashboardSectionApp     = "app"
+	DashboardSectionHome    = "home"
+)
+
 type CenterDashboardData struct {
 	User      UserInfoData      `json:"user"`
 	Traffic   UserTrafficData   `json:"traffic"`
@@ -17,6 +26,9 @@ type CenterDashboardData struct {
 	Tunnels   []TunnelItem      `json:"tunnels"`
 	App       AppVersionInfo    `json:"app"`
 	HomeStats HomeStatsData     `json:"home"`
+	// Errors maps a section key to the error that prevented it from loading.
+	// Sections listed here keep their zero value.
+	Errors map[string]string `json:"errors,omitempty"`
 }
 
 type TunnelOverviewData struct {
@@ -25,6 +37,9 @@ type TunnelOverviewData struct {
 	Limit     int64        `json:"limit"`
 	Total     int64        `json:"total"`
 	TotalPage int64        `json:"total_page"`
+	// TrafficUnavailable is set when the traffic of the requested days could not be
+	// loaded and every tunnel reports zero traffic.
+	TrafficUnavailable bool `json:"traffic_unavailable,omitempty"`
 }
 
 type RunnerData struct {
@@ -40,6 +55,9 @@ type RunnerRuntimeStatus struct {
 	StartedAt   string   `json:"started_at,omitempty"`
 	TunnelName  string   `json:"tunnel_name,omitempty"`
 	NodeAddress string   `json:"node_address,omitempty"`
+	NodeID      int64    `json:"node_id,omitempty"`
+	AccountID   int64    `json:"account_id,omitempty"`
+	AccountName string   `json:"account_name,omitempty"`
 	Command     string   `json:"command,omitempty"`
 	LastError   string   `json:"last_error,omitempty"`
 	LogLines    []string `json:"log_lines,omitempty"`
@@ -88,6 +106,7 @@ type TunnelItem struct {
 	RemotePort     int64  `json:"remote_port"`
 	Status         string `json:"status"`
 	Type           string `json:"type"`
+	CreatedAt      string `json:"created_at,omitempty"`
 	TotalIn        int64  `json:"total_in,omitempty"`
 	TotalOut       int64  `json:"total_out,omitempty"`
 	TotalTraffic   int64  `json:"total_traffic,omitempty"`
@@ -0,0 +1,18 @@
+package models
+
+type CheckInResult struct {
+	Reward       int64  `json:"reward"`
+	TrafficLimit int64  `json:"traffic_limit"`
+	Message      string `json:"message,omitempty"`
+}
+
+type CheckInRecord struct {
+	Date         string `json:"date"`
+	CheckedAt    string `json:"checked_at"`
+	UserID       int64  `json:"user_id"`
+	Automatic    bool   `json:"automatic"`
+	Success      bool   `json:"success"`
+	Reward       int64  `json:"reward"`
+	TrafficLimit int64  `json:"traffic_limit"`
+	Error        string `json:"error,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// Device login states.
+const (
+	DeviceLoginPending   = "pending"
+	DeviceLoginSucceeded = "succeeded"
+	DeviceLoginFailed    = "failed"
+	DeviceLoginExpired   = "expired"
+	DeviceLoginCancelled = "cancelled"
+)
+
+// DeviceLoginStatus describes an RFC 8628 device authorization login. The user opens
+// VerificationURI on any device and enters UserCode while the client polls for a token.
+type DeviceLoginStatus struct {
+	State                   string `json:"state"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresAt               string `json:"expires_at,omitempty"`
+	// Interval is the current polling interval in seconds; it grows on slow_down.
+	Interval int64  `json:"interval"`
+	Error    string `json:"error,omitempty"`
+}
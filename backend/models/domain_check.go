@@ -0,0 +1,33 @@
+package models
+
+// Domain check statuses.
+const (
+	DomainCheckOK       = "ok"
+	DomainCheckMismatch = "mismatch"
+	DomainCheckError    = "error"
+	DomainCheckSkipped  = "skipped"
+)
+
+// DomainCheckIssue is one problem found for a custom domain, with a hint on how to fix it.
+type DomainCheckIssue struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// DomainCheckResult is the DNS verification result of one custom domain.
+type DomainCheckResult struct {
+	TunnelName string `json:"tunnel_name"`
+	TunnelType string `json:"tunnel_type"`
+	Domain     string `json:"domain"`
+	NodeID     int64  `json:"node_id"`
+	NodeName   string `json:"node_name"`
+	// ExpectedHost is set when the node is addressed by hostname; a CNAME should point to it.
+	ExpectedHost string             `json:"expected_host,omitempty"`
+	ExpectedIPs  []string           `json:"expected_ips"`
+	CNAME        string             `json:"cname,omitempty"`
+	IPv4         []string           `json:"ipv4"`
+	IPv6         []string           `json:"ipv6"`
+	Status       string             `json:"status"`
+	Issues       []DomainCheckIssue `json:"issues"`
+	CheckedAt    string             `json:"checked_at"`
+}
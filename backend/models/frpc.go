@@ -16,6 +16,18 @@ type GitHubReleaseAsset struct {
 	Digest             string `json:"digest"`
 }
 
+// GitHubRateLimit is the API quota reported by the X-RateLimit-* headers of the last GitHub response.
+type GitHubRateLimit struct {
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+	Used      int    `json:"used"`
+	Resource  string `json:"resource,omitempty"`
+	ResetAt   string `json:"reset_at"`
+	CheckedAt string `json:"checked_at"`
+	// FromCache is set when the last release lookup was answered by a 304, which does not use quota.
+	FromCache bool `json:"from_cache"`
+}
+
 type FrpcReleaseAsset struct {
 	Name          string `json:"name"`
 	DownloadURL   string `json:"download_url"`
@@ -64,6 +76,9 @@ type FrpcStatus struct {
 	Latest          *FrpcReleaseInfo   `json:"latest,omitempty"`
 	UpdateAvailable bool               `json:"update_available"`
 	LatestError     string             `json:"latest_error,omitempty"`
+	// GitHubTokenSet reports whether a GitHub token from the keyring is sent with API requests.
+	GitHubTokenSet  bool             `json:"github_token_set"`
+	GitHubRateLimit *GitHubRateLimit `json:"github_rate_limit,omitempty"`
 }
 
 type FrpcInstallResult struct {
@@ -0,0 +1,37 @@
+package models
+
+// Verification states of a generated frpc config.
+const (
+	FrpcVerifyOK      = "ok"
+	FrpcVerifyFailed  = "failed"
+	FrpcVerifySkipped = "skipped"
+)
+
+type FrpcConfigOptions struct {
+	TunnelNames []string `json:"tunnel_names"`
+	// Group puts tunnels on the same node into one file.
+	Group bool `json:"group"`
+	// Verify runs the installed frpc's verify command on every file.
+	Verify bool `json:"verify"`
+	// OutputDir, when set, receives one .toml file per generated config.
+	OutputDir string `json:"output_dir"`
+}
+
+type GeneratedFrpcConfig struct {
+	FileName     string   `json:"file_name"`
+	Path         string   `json:"path,omitempty"`
+	NodeID       int64    `json:"node_id"`
+	NodeName     string   `json:"node_name"`
+	ServerAddr   string   `json:"server_addr"`
+	ServerPort   int64    `json:"server_port"`
+	Tunnels      []string `json:"tunnels"`
+	Content      string   `json:"content"`
+	VerifyStatus string   `json:"verify_status"`
+	VerifyOutput string   `json:"verify_output,omitempty"`
+}
+
+type FrpcConfigBundle struct {
+	Configs []GeneratedFrpcConfig `json:"configs"`
+	// VerifyNote explains why verification was skipped.
+	VerifyNote string `json:"verify_note,omitempty"`
+}
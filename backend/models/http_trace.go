@@ -0,0 +1,29 @@
+package models
+
+// HTTPTraceEntry is one recorded HTTP round trip. Secrets are redacted and bodies truncated.
+type HTTPTraceEntry struct {
+	ID                int64             `json:"id"`
+	Source            string            `json:"source"`
+	Method            string            `json:"method"`
+	URL               string            `json:"url"`
+	Path              string            `json:"path"`
+	StatusCode        int               `json:"status_code"`
+	Error             string            `json:"error,omitempty"`
+	StartedAt         string            `json:"started_at"`
+	DurationMs        int64             `json:"duration_ms"`
+	RequestHeaders    map[string]string `json:"request_headers,omitempty"`
+	RequestBody       string            `json:"request_body,omitempty"`
+	RequestSize       int64             `json:"request_size"`
+	RequestTruncated  bool              `json:"request_truncated,omitempty"`
+	ResponseHeaders   map[string]string `json:"response_headers,omitempty"`
+	ResponseBody      string            `json:"response_body,omitempty"`
+	ResponseSize      int64             `json:"response_size"`
+	ResponseTruncated bool              `json:"response_truncated,omitempty"`
+}
+
+// HTTPTraceStatus describes the HTTP debug trace.
+type HTTPTraceStatus struct {
+	Enabled  bool   `json:"enabled"`
+	FilePath string `json:"file_path"`
+	Entries  int    `json:"entries"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// ProxyTestTarget is the outcome of reaching one endpoint through the proxy under test.
+type ProxyTestTarget struct {
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	ProxyURL   string `json:"proxy_url,omitempty"`
+	Reachable  bool   `json:"reachable"`
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+	// Certificates is the chain the server presented when TLS verification failed.
+	Certificates []CertificateInfo `json:"certificates,omitempty"`
+}
+
+// ProxyTestResult reports whether the Center API and GitHub are reachable with a proxy configuration.
+type ProxyTestResult struct {
+	Mode    string            `json:"mode"`
+	Success bool              `json:"success"`
+	Targets []ProxyTestTarget `json:"targets"`
+}
+
+// CertificateInfo describes one certificate of a server's TLS chain, leaf first.
+type CertificateInfo struct {
+	Subject      string   `json:"subject"`
+	Issuer       string   `json:"issuer"`
+	SerialNumber string   `json:"serial_number"`
+	NotBefore    string   `json:"not_before"`
+	NotAfter     string   `json:"not_after"`
+	DNSNames     []string `json:"dns_names,omitempty"`
+	IsCA         bool     `json:"is_ca"`
+	SHA256       string   `json:"sha256"`
+	SPKIPin      string   `json:"spki_pin"`
+}
@@ -0,0 +1,34 @@
+package models
+
+type NodeProbeOptions struct {
+	Samples        int    `json:"samples"`
+	TimeoutMs      int    `json:"timeout_ms"`
+	Protocol       string `json:"protocol"`
+	IncludeOffline bool   `json:"include_offline"`
+	Force          bool   `json:"force"`
+}
+
+type NodeProbeResult struct {
+	Node          NodeItem `json:"node"`
+	Address       string   `json:"address"`
+	Samples       int      `json:"samples"`
+	Successes     int      `json:"successes"`
+	AvgRTTMs      float64  `json:"avg_rtt_ms"`
+	MinRTTMs      float64  `json:"min_rtt_ms"`
+	MaxRTTMs      float64  `json:"max_rtt_ms"`
+	JitterMs      float64  `json:"jitter_ms"`
+	LossRate      float64  `json:"loss_rate"`
+	Reachable     bool     `json:"reachable"`
+	LastError     string   `json:"last_error,omitempty"`
+	Rank          int      `json:"rank"`
+	FilteredOut   bool     `json:"filtered_out"`
+	FilterReasons []string `json:"filter_reasons,omitempty"`
+}
+
+type NodeProbeReport struct {
+	ProbedAt    string            `json:"probed_at"`
+	ExpiresAt   string            `json:"expires_at"`
+	Cached      bool              `json:"cached"`
+	Results     []NodeProbeResult `json:"results"`
+	Recommended *NodeProbeResult  `json:"recommended,omitempty"`
+}
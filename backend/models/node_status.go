@@ -0,0 +1,57 @@
+package models
+
+// Runner actions taken by the node watcher.
+const (
+	NodeRunnerActionNone    = "none"
+	NodeRunnerActionPaused  = "paused"
+	NodeRunnerActionResumed = "resumed"
+)
+
+// NodeStatusTransition records a node changing status. From is empty for the first observation.
+type NodeStatusTransition struct {
+	NodeID   int64  `json:"node_id"`
+	NodeName string `json:"node_name"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Online   bool   `json:"online"`
+	LastSeen string `json:"last_seen"`
+	At       string `json:"at"`
+}
+
+// NodeRunnerImpact is emitted when the node backing the running tunnel goes offline or comes back.
+type NodeRunnerImpact struct {
+	Transition NodeStatusTransition `json:"transition"`
+	TunnelName string               `json:"tunnel_name"`
+	AccountID  int64                `json:"account_id"`
+	Action     string               `json:"action"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// NodeUptime summarizes a node's observed availability over a period.
+type NodeUptime struct {
+	NodeID        int64                  `json:"node_id"`
+	NodeName      string                 `json:"node_name"`
+	Status        string                 `json:"status"`
+	Online        bool                   `json:"online"`
+	LastSeen      string                 `json:"last_seen"`
+	Since         string                 `json:"since"`
+	UptimePercent float64                `json:"uptime_percent"`
+	OutageCount   int                    `json:"outage_count"`
+	Transitions   []NodeStatusTransition `json:"transitions"`
+}
+
+// PausedRunner describes a runner stopped by the node watcher and waiting for its node.
+type PausedRunner struct {
+	TunnelName string `json:"tunnel_name"`
+	NodeID     int64  `json:"node_id"`
+	AccountID  int64  `json:"account_id"`
+	PausedAt   string `json:"paused_at"`
+}
+
+// NodeWatcherStatus reports the watcher state.
+type NodeWatcherStatus struct {
+	LastCheckedAt string        `json:"last_checked_at"`
+	LastError     string        `json:"last_error,omitempty"`
+	AutoPause     bool          `json:"auto_pause"`
+	PausedRunner  *PausedRunner `json:"paused_runner,omitempty"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// OAuthPortFallback reports that the login callback port was taken and the redirect
+// was moved to another port.
+type OAuthPortFallback struct {
+	BusyPorts []int `json:"busy_ports"`
+	// Owners names the processes holding the busy ports, when they can be found.
+	Owners string `json:"owners,omitempty"`
+	Port   int    `json:"port"`
+}
@@ -0,0 +1,31 @@
+package models
+
+type TrafficExportOptions struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Format    string `json:"format"`
+	Path      string `json:"path"`
+}
+
+type TrafficExportRow struct {
+	Date         string `json:"date"`
+	TunnelName   string `json:"tunnel_name"`
+	Remark       string `json:"remark"`
+	TotalTraffic int64  `json:"total_traffic"`
+}
+
+type TrafficExportDocument struct {
+	StartDate   string             `json:"start_date"`
+	EndDate     string             `json:"end_date"`
+	GeneratedAt string             `json:"generated_at"`
+	Rows        []TrafficExportRow `json:"rows"`
+}
+
+type TrafficExportResult struct {
+	Path      string `json:"path"`
+	Format    string `json:"format"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	RowCount  int    `json:"row_count"`
+	Cancelled bool   `json:"cancelled"`
+}
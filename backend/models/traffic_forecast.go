@@ -0,0 +1,32 @@
+package models
+
+type TunnelBurnContribution struct {
+	TunnelName   string  `json:"tunnel_name"`
+	Remark       string  `json:"remark"`
+	DailyAverage float64 `json:"daily_average"`
+	Share        float64 `json:"share"`
+}
+
+type TrafficForecast struct {
+	GeneratedAt      string  `json:"generated_at"`
+	TrafficLimit     int64   `json:"traffic_limit"`
+	TrafficUsed      int64   `json:"traffic_used"`
+	TrafficRemaining int64   `json:"traffic_remaining"`
+	WindowDays       int     `json:"window_days"`
+	MovingAverage    float64 `json:"moving_average"`
+	TrendPerDay      float64 `json:"trend_per_day"`
+	DailyBurnRate    float64 `json:"daily_burn_rate"`
+	Confidence       float64 `json:"confidence"`
+
+	// WillExhaust is false when the quota is not expected to run out within the forecast horizon.
+	WillExhaust            bool    `json:"will_exhaust"`
+	DaysRemaining          float64 `json:"days_remaining"`
+	DaysRemainingLow       float64 `json:"days_remaining_low"`
+	DaysRemainingHigh      float64 `json:"days_remaining_high"`
+	ExhaustionDate         string  `json:"exhaustion_date,omitempty"`
+	ExhaustionDateEarliest string  `json:"exhaustion_date_earliest,omitempty"`
+	ExhaustionDateLatest   string  `json:"exhaustion_date_latest,omitempty"`
+	HorizonDays            int     `json:"horizon_days"`
+
+	Tunnels []TunnelBurnContribution `json:"tunnels"`
+}
@@ -0,0 +1,46 @@
+package models
+
+type TrafficHistoryEntry struct {
+	Date         string `json:"date"`
+	TunnelName   string `json:"tunnel_name"`
+	Remark       string `json:"remark"`
+	TotalTraffic int64  `json:"total_traffic"`
+	UpdatedAt    string `json:"updated_at"`
+}
+
+type TunnelTotalsSnapshot struct {
+	CapturedOn string              `json:"captured_on"`
+	Days       int64               `json:"days"`
+	Tunnels    []TrafficTunnelItem `json:"tunnels"`
+}
+
+type UserTrafficSnapshot struct {
+	Date    string          `json:"date"`
+	Traffic UserTrafficData `json:"traffic"`
+}
+
+type MonthlyTrafficTotal struct {
+	Month        string           `json:"month"`
+	TotalTraffic int64            `json:"total_traffic"`
+	Days         int              `json:"days"`
+	Tunnels      map[string]int64 `json:"tunnels"`
+}
+
+type TunnelTrafficPoint struct {
+	Date         string `json:"date"`
+	TotalTraffic int64  `json:"total_traffic"`
+}
+
+type TunnelTrafficHistory struct {
+	TunnelName   string               `json:"tunnel_name"`
+	Remark       string               `json:"remark"`
+	TotalTraffic int64                `json:"total_traffic"`
+	Points       []TunnelTrafficPoint `json:"points"`
+}
+
+type TrafficHistorySnapshotResult struct {
+	UserID        int64  `json:"user_id"`
+	CapturedAt    string `json:"captured_at"`
+	DailyEntries  int    `json:"daily_entries"`
+	TunnelEntries int    `json:"tunnel_entries"`
+}
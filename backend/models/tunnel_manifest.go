@@ -0,0 +1,109 @@
+package models
+
+// TunnelSpec is the writable part of a tunnel, sent to the tunnel management API.
+type TunnelSpec struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	NodeID       int64  `json:"node_id"`
+	LocalIP      string `json:"local_ip"`
+	LocalPort    int64  `json:"local_port"`
+	RemotePort   int64  `json:"remote_port,omitempty"`
+	CustomDomain string `json:"custom_domain,omitempty"`
+	Remark       string `json:"remark,omitempty"`
+}
+
+// TunnelDefinition is one tunnel in an exported tunnel file. NodeName is informative;
+// it is used to find the node only when NodeID is missing.
+type TunnelDefinition struct {
+	Name         string `json:"name" yaml:"name" toml:"name"`
+	Type         string `json:"type" yaml:"type" toml:"type"`
+	NodeID       int64  `json:"node_id" yaml:"node_id" toml:"node_id"`
+	NodeName     string `json:"node_name,omitempty" yaml:"node_name,omitempty" toml:"node_name,omitempty"`
+	LocalIP      string `json:"local_ip" yaml:"local_ip" toml:"local_ip"`
+	LocalPort    int64  `json:"local_port" yaml:"local_port" toml:"local_port"`
+	RemotePort   int64  `json:"remote_port,omitempty" yaml:"remote_port,omitempty" toml:"remote_port,omitzero"`
+	CustomDomain string `json:"custom_domain,omitempty" yaml:"custom_domain,omitempty" toml:"custom_domain,omitempty"`
+	Remark       string `json:"remark,omitempty" yaml:"remark,omitempty" toml:"remark,omitempty"`
+}
+
+// Spec returns the definition as an API request body.
+func (d TunnelDefinition) Spec() TunnelSpec {
+	return TunnelSpec{
+		Name:         d.Name,
+		Type:         d.Type,
+		NodeID:       d.NodeID,
+		LocalIP:      d.LocalIP,
+		LocalPort:    d.LocalPort,
+		RemotePort:   d.RemotePort,
+		CustomDomain: d.CustomDomain,
+		Remark:       d.Remark,
+	}
+}
+
+// Tunnel import change actions.
+const (
+	TunnelChangeCreate = "create"
+	TunnelChangeUpdate = "update"
+	TunnelChangeDelete = "delete"
+)
+
+type TunnelExportOptions struct {
+	// Format is "yaml" or "toml"; empty picks it from the path extension, defaulting to yaml.
+	Format string `json:"format"`
+	Path   string `json:"path"`
+}
+
+type TunnelExportResult struct {
+	Path        string `json:"path"`
+	Format      string `json:"format"`
+	TunnelCount int    `json:"tunnel_count"`
+	Cancelled   bool   `json:"cancelled"`
+}
+
+type TunnelImportOptions struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+	// Prune deletes tunnels of the account that are not in the file.
+	Prune bool `json:"prune"`
+	// PlanDigest is the Digest of the previewed plan. ApplyTunnelImport refuses to run
+	// when the plan it computes no longer has this digest.
+	PlanDigest string `json:"plan_digest"`
+}
+
+// TunnelChange is one difference between the file and the account.
+type TunnelChange struct {
+	Action string            `json:"action"`
+	Name   string            `json:"name"`
+	Before *TunnelDefinition `json:"before,omitempty"`
+	After  *TunnelDefinition `json:"after,omitempty"`
+	// Fields lists the changed fields of an update.
+	Fields []string `json:"fields,omitempty"`
+}
+
+type TunnelImportPlan struct {
+	Path    string         `json:"path"`
+	Format  string         `json:"format"`
+	Creates []TunnelChange `json:"creates"`
+	Updates []TunnelChange `json:"updates"`
+	Deletes []TunnelChange `json:"deletes"`
+	// Unchanged lists tunnels that already match the file.
+	Unchanged []string `json:"unchanged"`
+	// Untracked lists account tunnels missing from the file that are kept because Prune is off.
+	Untracked []string `json:"untracked"`
+	// Digest identifies the creates, updates and deletes of the plan.
+	Digest    string `json:"digest"`
+	Cancelled bool   `json:"cancelled"`
+}
+
+type TunnelChangeResult struct {
+	TunnelChange
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+type TunnelImportResult struct {
+	Plan    TunnelImportPlan     `json:"plan"`
+	Results []TunnelChangeResult `json:"results"`
+	Applied int                  `json:"applied"`
+	Failed  int                  `json:"failed"`
+}
@@ -0,0 +1,27 @@
+package models
+
+// Tunnel sort keys accepted by TunnelQuery.SortBy.
+const (
+	TunnelSortTraffic = "traffic"
+	TunnelSortName    = "name"
+	TunnelSortCreated = "created"
+)
+
+// TunnelQuery filters, sorts and paginates the tunnel overview. Empty filters match everything.
+type TunnelQuery struct {
+	// Search matches name, remark and custom domain, case-insensitively; every word must match.
+	Search   string   `json:"search"`
+	Types    []string `json:"types"`
+	Statuses []string `json:"statuses"`
+	NodeIDs  []int64  `json:"node_ids"`
+	// SortBy is one of the TunnelSort keys; empty keeps the API order.
+	SortBy string `json:"sort_by"`
+	// SortDesc reverses the order. Traffic and created time sort descending when unset.
+	SortDesc *bool `json:"sort_desc,omitempty"`
+	Page     int   `json:"page"`
+	Limit    int   `json:"limit"`
+	// All returns every matching tunnel on a single page; Page and Limit are ignored.
+	All bool `json:"all"`
+	// Days is the traffic window joined into each tunnel; 0 skips traffic unless sorting by it.
+	Days int `json:"days"`
+}
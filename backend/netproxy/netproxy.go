@@ -0,0 +1,172 @@
+// Package netproxy resolves the outbound proxy configured in config.ProxyConfig.
+package netproxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"loliashizuku/backend/config"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Normalize trims cfg, fills the default mode and validates the proxy URL.
+func Normalize(cfg config.ProxyConfig) (config.ProxyConfig, error) {
+	cfg.Mode = strings.ToLower(strings.TrimSpace(cfg.Mode))
+	cfg.URL = strings.TrimSpace(cfg.URL)
+	cfg.NoProxy = strings.TrimSpace(cfg.NoProxy)
+	if cfg.Mode == "" {
+		cfg.Mode = config.ProxyModeSystem
+	}
+
+	switch cfg.Mode {
+	case config.ProxyModeNone, config.ProxyModeSystem:
+		return cfg, nil
+	case config.ProxyModeManual:
+		if _, err := ParseURL(cfg.URL); err != nil {
+			return cfg, err
+		}
+		return cfg, nil
+	default:
+		return cfg, fmt.Errorf("unsupported proxy mode %q", cfg.Mode)
+	}
+}
+
+// ParseURL parses a proxy URL. A URL without scheme is treated as an HTTP proxy.
+func ParseURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("proxy url is empty")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse proxy url: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("proxy url %q has no host", raw)
+	}
+	if u.Port() == "" {
+		return nil, fmt.Errorf("proxy url %q has no port", raw)
+	}
+	return u, nil
+}
+
+// ProxyFunc returns the http.Transport proxy function for cfg. Mode none returns nil.
+func ProxyFunc(cfg config.ProxyConfig) (func(*http.Request) (*url.URL, error), error) {
+	cfg, err := Normalize(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var resolve func(*url.URL) (*url.URL, error)
+	switch cfg.Mode {
+	case config.ProxyModeNone:
+		return nil, nil
+	case config.ProxyModeSystem:
+		resolve = httpproxy.FromEnvironment().ProxyFunc()
+	case config.ProxyModeManual:
+		proxyURL, _ := ParseURL(cfg.URL)
+		resolve = (&httpproxy.Config{
+			HTTPProxy:  proxyURL.String(),
+			HTTPSProxy: proxyURL.String(),
+			NoProxy:    cfg.NoProxy,
+		}).ProxyFunc()
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		return resolve(req.URL)
+	}, nil
+}
+
+// URLFor returns the proxy used for target under cfg, or nil for a direct connection.
+func URLFor(cfg config.ProxyConfig, target *url.URL) (*url.URL, error) {
+	proxy, err := ProxyFunc(cfg)
+	if err != nil || proxy == nil {
+		return nil, err
+	}
+	return proxy(&http.Request{URL: target})
+}
+
+// NewTransport returns a transport that asks current for the proxy settings on every
+// request, so changes apply without rebuilding clients. Invalid settings fail the request.
+func NewTransport(current func() config.ProxyConfig) *http.Transport {
+	transport := newBaseTransport()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if current == nil {
+			return http.ProxyFromEnvironment(req)
+		}
+		proxy, err := ProxyFunc(current())
+		if err != nil || proxy == nil {
+			return nil, err
+		}
+		return proxy(req)
+	}
+	return transport
+}
+
+// NewStaticTransport returns a transport that always uses cfg, e.g. to test settings
+// before they are saved.
+func NewStaticTransport(cfg config.ProxyConfig) (*http.Transport, error) {
+	proxy, err := ProxyFunc(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport := newBaseTransport()
+	transport.Proxy = proxy
+	return transport, nil
+}
+
+func newBaseTransport() *http.Transport {
+	if base, ok := http.DefaultTransport.(*http.Transport); ok {
+		return base.Clone()
+	}
+	return &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// FrpcEnv returns the environment variables that make frpc dial its server through
+// the proxy in cfg. frpc reads http_proxy when its config sets no transport.proxyURL.
+// Only manual proxies are passed on; in system mode frpc already inherits the environment.
+func FrpcEnv(cfg config.ProxyConfig) []string {
+	cfg, err := Normalize(cfg)
+	if err != nil || !cfg.ApplyToFrpc || cfg.Mode != config.ProxyModeManual {
+		return nil
+	}
+	proxyURL, err := ParseURL(cfg.URL)
+	if err != nil {
+		return nil
+	}
+	return []string{"http_proxy=" + proxyURL.String(), "HTTP_PROXY=" + proxyURL.String()}
+}
+
+// FrpcProxyURL returns the value for frpc's transport.proxyURL, or "" when frpc should
+// connect directly. In system mode the environment's proxy for the server is used.
+func FrpcProxyURL(cfg config.ProxyConfig, serverAddr string, serverPort int64) string {
+	cfg, err := Normalize(cfg)
+	if err != nil || !cfg.ApplyToFrpc {
+		return ""
+	}
+	target := &url.URL{Scheme: "http", Host: net.JoinHostPort(serverAddr, fmt.Sprint(serverPort))}
+	proxyURL, err := URLFor(cfg, target)
+	if err != nil || proxyURL == nil {
+		return ""
+	}
+	return proxyURL.String()
+}
@@ -0,0 +1,48 @@
+// Package portowner finds the processes listening on a local TCP port so a failed
+// bind can name what holds the port.
+package portowner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Process is a process listening on a port. Name is empty when it could not be read.
+type Process struct {
+	PID  int
+	Name string
+}
+
+func (p Process) String() string {
+	if p.Name == "" {
+		return fmt.Sprintf("PID %d", p.PID)
+	}
+	return fmt.Sprintf("%s (PID %d)", p.Name, p.PID)
+}
+
+// Describe joins processes for display, e.g. "frps (PID 4242)".
+func Describe(processes []Process) string {
+	names := make([]string, 0, len(processes))
+	for _, process := range processes {
+		names = append(names, process.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// Lookup returns the processes listening on TCP port on any local address. Processes of
+// other users may be missing when the OS does not expose them.
+func Lookup(port int) ([]Process, error) {
+	if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	return lookup(port)
+}
+
+func appendUnique(processes []Process, process Process) []Process {
+	for _, existing := range processes {
+		if existing.PID == process.PID {
+			return processes
+		}
+	}
+	return append(processes, process)
+}
@@ -0,0 +1,101 @@
+//go:build linux
+
+package portowner
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const tcpListenState = "0A"
+
+// IsAddrInUse reports whether err is a bind failure because the address is taken.
+func IsAddrInUse(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE)
+}
+
+// lookup maps the listening socket inodes in /proc/net/tcp{,6} to the processes
+// holding them open.
+func lookup(port int) ([]Process, error) {
+	inodes := map[string]struct{}{}
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		if err := collectListenInodes(table, port, inodes); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if len(inodes) == 0 {
+		return nil, nil
+	}
+
+	pids, err := filepath.Glob("/proc/[0-9]*")
+	if err != nil {
+		return nil, err
+	}
+	var processes []Process
+	for _, dir := range pids {
+		pid, err := strconv.Atoi(filepath.Base(dir))
+		if err != nil {
+			continue
+		}
+		fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+		if err != nil {
+			// Other users' processes are not readable without privileges.
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+			if err != nil {
+				continue
+			}
+			inode, ok := strings.CutPrefix(target, "socket:[")
+			if !ok {
+				continue
+			}
+			if _, ok := inodes[strings.TrimSuffix(inode, "]")]; ok {
+				processes = appendUnique(processes, Process{PID: pid, Name: processName(dir)})
+				break
+			}
+		}
+	}
+	return processes, nil
+}
+
+func collectListenInodes(table string, port int, inodes map[string]struct{}) error {
+	file, err := os.Open(table)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != tcpListenState {
+			continue
+		}
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		localPort, err := strconv.ParseUint(hexPort, 16, 16)
+		if err != nil || int(localPort) != port {
+			continue
+		}
+		inodes[fields[9]] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+func processName(dir string) string {
+	comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
@@ -0,0 +1,52 @@
+//go:build !linux && !windows
+
+package portowner
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// IsAddrInUse reports whether err is a bind failure because the address is taken.
+func IsAddrInUse(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE)
+}
+
+// lookup asks lsof for the listeners on port.
+func lookup(port int) ([]Process, error) {
+	output, err := exec.Command("lsof", "-nP", "-iTCP:"+strconv.Itoa(port), "-sTCP:LISTEN", "-Fpc").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(output) == 0 {
+		// lsof exits with 1 when nothing matches.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []Process
+	var current *Process
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			pid, err := strconv.Atoi(line[1:])
+			if err != nil {
+				current = nil
+				continue
+			}
+			processes = appendUnique(processes, Process{PID: pid})
+			current = &processes[len(processes)-1]
+		case 'c':
+			if current != nil {
+				current.Name = line[1:]
+			}
+		}
+	}
+	return processes, nil
+}
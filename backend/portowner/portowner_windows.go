@@ -0,0 +1,69 @@
+//go:build windows
+
+package portowner
+
+import (
+	"encoding/csv"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	windowsCreateNoWindow uint32 = 0x08000000
+	wsaeAddrInUse                = syscall.Errno(10048)
+)
+
+// IsAddrInUse reports whether err is a bind failure because the address is taken.
+func IsAddrInUse(err error) bool {
+	return errors.Is(err, wsaeAddrInUse) || errors.Is(err, syscall.EADDRINUSE)
+}
+
+// lookup reads the listening sockets from netstat and names their owners with tasklist.
+func lookup(port int) ([]Process, error) {
+	output, err := hiddenCommand("netstat", "-ano", "-p", "TCP").Output()
+	if err != nil {
+		return nil, err
+	}
+	// netstat -p TCP lists IPv4 only; TCPv6 covers [::1] and [::].
+	if v6, err := hiddenCommand("netstat", "-ano", "-p", "TCPv6").Output(); err == nil {
+		output = append(output, v6...)
+	}
+
+	suffix := ":" + strconv.Itoa(port)
+	var processes []Process
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		// Proto, local address, foreign address, state, PID. The state is localized,
+		// so a listener is recognized by its unspecified foreign port.
+		if len(fields) != 5 || !strings.HasSuffix(fields[1], suffix) || !strings.HasSuffix(fields[2], ":0") {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[4])
+		if err != nil || pid == 0 {
+			continue
+		}
+		processes = appendUnique(processes, Process{PID: pid, Name: processName(pid)})
+	}
+	return processes, nil
+}
+
+func processName(pid int) string {
+	output, err := hiddenCommand("tasklist", "/FO", "CSV", "/NH", "/FI", "PID eq "+strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	record, err := csv.NewReader(strings.NewReader(string(output))).Read()
+	if err != nil || len(record) < 2 || record[1] != strconv.Itoa(pid) {
+		return ""
+	}
+	return record[0]
+}
+
+func hiddenCommand(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: windowsCreateNoWindow}
+	return cmd
+}
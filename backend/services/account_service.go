@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"loliashizuku/backend/httpclient"
+	"loliashizuku/backend/models"
+
+	"golang.org/x/oauth2"
+)
+
+// AccountService lets the frontend list, switch, rename and remove signed-in accounts.
+type AccountService struct {
+	center *CenterService
+}
+
+// NewAccountService creates a new AccountService.
+func NewAccountService(center *CenterService) *AccountService {
+	return &AccountService{center: center}
+}
+
+// ListAccounts returns every known account, most recently used first.
+func (s *AccountService) ListAccounts() ([]models.Account, error) {
+	return listAccounts()
+}
+
+// GetActiveAccount returns the account used for Center API calls, or nil when none is selected.
+func (s *AccountService) GetActiveAccount() (*models.Account, error) {
+	return activeAccount(), nil
+}
+
+// SwitchAccount makes another signed-in account active. Runners keep running under
+// the account that started them.
+func (s *AccountService) SwitchAccount(userID int64) (*models.Account, error) {
+	return switchAccount(userID)
+}
+
+// RenameAccount sets a local alias for the account. An empty alias shows the username again.
+func (s *AccountService) RenameAccount(userID int64, alias string) (*models.Account, error) {
+	return renameAccount(userID, alias)
+}
+
+// RemoveAccount forgets the account, deletes its token and stops the runner it started.
+func (s *AccountService) RemoveAccount(userID int64) error {
+	s.center.stopRunnerOfAccount(userID)
+	return removeAccount(userID)
+}
+
+type accessTokenOverrideKey struct{}
+
+// withAccessToken makes Center API calls made with ctx use token instead of the
+// active account's, e.g. to identify a freshly issued token before it is stored.
+func withAccessToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, accessTokenOverrideKey{}, token)
+}
+
+func accessTokenOverride(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(accessTokenOverrideKey{}).(string)
+	return token, ok && token != ""
+}
+
+type tokenKeyRecorderKey struct{}
+
+// tokenKeyRecorder remembers which keyring key supplied the token of one request, so
+// a 401 that arrives after an account switch signs out the account that sent it.
+type tokenKeyRecorder struct {
+	mu  sync.Mutex
+	key string
+}
+
+// recordTokenKey attaches an empty tokenKeyRecorder to every request.
+func recordTokenKey() httpclient.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := context.WithValue(req.Context(), tokenKeyRecorderKey{}, &tokenKeyRecorder{})
+			return next.RoundTrip(req.WithContext(ctx))
+		})
+	}
+}
+
+func setRequestTokenKey(ctx context.Context, key string) {
+	if recorder, ok := ctx.Value(tokenKeyRecorderKey{}).(*tokenKeyRecorder); ok {
+		recorder.mu.Lock()
+		recorder.key = key
+		recorder.mu.Unlock()
+	}
+}
+
+// requestTokenKey returns the keyring key whose token the request was sent with.
+func requestTokenKey(ctx context.Context) (string, bool) {
+	recorder, ok := ctx.Value(tokenKeyRecorderKey{}).(*tokenKeyRecorder)
+	if !ok {
+		return "", false
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.key, recorder.key != ""
+}
+
+type accountIdentityResolver func(ctx context.Context, token *oauth2.Token) (*models.UserInfoData, error)
+
+var (
+	identityResolverMu sync.Mutex
+	identityResolver   accountIdentityResolver
+)
+
+func setAccountIdentityResolver(resolver accountIdentityResolver) {
+	identityResolverMu.Lock()
+	identityResolver = resolver
+	identityResolverMu.Unlock()
+}
+
+// completeOAuthLogin files a newly issued token under the account it belongs to and
+// makes that account active. When the account cannot be identified yet the token is
+// kept unassigned and adopted on the next start.
+func completeOAuthLogin(ctx context.Context, token *oauth2.Token) error {
+	identityResolverMu.Lock()
+	resolver := identityResolver
+	identityResolverMu.Unlock()
+
+	if resolver != nil {
+		user, err := resolver(ctx, token)
+		if err == nil {
+			_, err = registerAccount(user, token)
+			return err
+		}
+	}
+
+	if err := deactivateAccount(false); err != nil {
+		return err
+	}
+	if err := writeOAuthToken(oauthTokenKey, token); err != nil {
+		return err
+	}
+	notifyAccountChanged()
+	return nil
+}
+
+func (s *CenterService) resolveAccountIdentity(ctx context.Context, token *oauth2.Token) (*models.UserInfoData, error) {
+	// The token may belong to another account than the active one, so the lookup must
+	// neither join a cached /user/info load nor store its result.
+	return s.api.GetUserInfoUncached(withAccessToken(ctx, token.AccessToken))
+}
+
+// adoptUnassignedToken moves a token saved before accounts existed (or by a login
+// whose identity lookup failed) under its account.
+func (s *CenterService) adoptUnassignedToken() {
+	if activeAccount() != nil {
+		return
+	}
+	if _, err := readOAuthToken(oauthTokenKey); err != nil {
+		return
+	}
+
+	ctx, done := s.requests.begin("", defaultRequestTimeout)
+	defer done()
+
+	// With no active account this loads, and if needed refreshes, the unassigned token.
+	token, err := loadOrRefreshOAuthToken(ctx, oauthTokenKey)
+	if err != nil {
+		return
+	}
+	user, err := s.resolveAccountIdentity(ctx, token)
+	if err != nil {
+		return
+	}
+	_, _ = registerAccount(user, token)
+}
+
+func (s *CenterService) stopRunnerOfAccount(userID int64) {
+	s.runnerMu.Lock()
+	owned := s.isRunnerRunningLocked() && s.runnerAccountID == userID
+	s.runnerMu.Unlock()
+	if owned {
+		_, _ = s.StopRunner()
+	}
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"loliashizuku/backend/apperror"
+	"loliashizuku/backend/models"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
+)
+
+const accountsFile = "accounts.json"
+
+// accountIndex is the on-disk list of accounts. The token of account N lives in the
+// keyring under "oauth_token:N"; the legacy "oauth_token" key holds a token whose
+// account is not known yet and is used while ActiveID is 0.
+type accountIndex struct {
+	ActiveID int64            `json:"active_id"`
+	Accounts []models.Account `json:"accounts"`
+}
+
+var (
+	accountsMu     sync.Mutex
+	accountsLoaded bool
+	accounts       accountIndex
+)
+
+func accountTokenKey(userID int64) string {
+	return oauthTokenKey + ":" + strconv.FormatInt(userID, 10)
+}
+
+// activeTokenKey returns the keyring key of the active account's token.
+func activeTokenKey() string {
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+	if err := loadAccountsLocked(); err != nil || accounts.ActiveID == 0 {
+		return oauthTokenKey
+	}
+	return accountTokenKey(accounts.ActiveID)
+}
+
+// activeAccount returns the active account, or nil when none is selected.
+func activeAccount() *models.Account {
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+	if err := loadAccountsLocked(); err != nil || accounts.ActiveID == 0 {
+		return nil
+	}
+	if index := findAccountLocked(accounts.ActiveID); index >= 0 {
+		account := accounts.Accounts[index]
+		account.Active = true
+		return &account
+	}
+	return nil
+}
+
+func listAccounts() ([]models.Account, error) {
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+	if err := loadAccountsLocked(); err != nil {
+		return nil, err
+	}
+
+	list := make([]models.Account, len(accounts.Accounts))
+	for i, account := range accounts.Accounts {
+		account.Active = account.ID == accounts.ActiveID
+		list[i] = account
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].LastUsedAt > list[j].LastUsedAt
+	})
+	return list, nil
+}
+
+// registerAccount stores token for user, adds or refreshes the account entry and makes it active.
+func registerAccount(user *models.UserInfoData, token *oauth2.Token) (*models.Account, error) {
+	if user == nil || user.ID <= 0 {
+		return nil, fmt.Errorf("account user id is empty")
+	}
+	if err := writeOAuthToken(accountTokenKey(user.ID), token); err != nil {
+		return nil, err
+	}
+
+	accountsMu.Lock()
+	if err := loadAccountsLocked(); err != nil {
+		accountsMu.Unlock()
+		return nil, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	index := findAccountLocked(user.ID)
+	if index < 0 {
+		accounts.Accounts = append(accounts.Accounts, models.Account{ID: user.ID, AddedAt: now})
+		index = len(accounts.Accounts) - 1
+	}
+	account := &accounts.Accounts[index]
+	account.Username = strings.TrimSpace(user.Username)
+	account.Email = strings.TrimSpace(user.Email)
+	account.Avatar = strings.TrimSpace(user.Avatar)
+	account.LastUsedAt = now
+	account.SignedIn = true
+	accounts.ActiveID = user.ID
+
+	result := *account
+	result.Active = true
+	err := saveAccountsLocked()
+	accountsMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// The token now lives under the account key; drop a leftover unassigned token.
+	if err := keyring.Delete(tokenService, oauthTokenKey); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("remove unassigned oauth token: %w", err)
+	}
+	notifyAccountChanged()
+	return &result, nil
+}
+
+func switchAccount(userID int64) (*models.Account, error) {
+	accountsMu.Lock()
+	if err := loadAccountsLocked(); err != nil {
+		accountsMu.Unlock()
+		return nil, err
+	}
+	index := findAccountLocked(userID)
+	if index < 0 {
+		accountsMu.Unlock()
+		return nil, apperror.New(apperror.CodeAccountNotFound, "account", strconv.FormatInt(userID, 10))
+	}
+	account := &accounts.Accounts[index]
+	if !account.SignedIn {
+		accountsMu.Unlock()
+		return nil, apperror.New(apperror.CodeAccountSignedOut, "account", account.DisplayName())
+	}
+	if accounts.ActiveID == userID {
+		result := *account
+		result.Active = true
+		accountsMu.Unlock()
+		return &result, nil
+	}
+
+	account.LastUsedAt = time.Now().UTC().Format(time.RFC3339)
+	accounts.ActiveID = userID
+	result := *account
+	result.Active = true
+	err := saveAccountsLocked()
+	accountsMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	notifyAccountChanged()
+	return &result, nil
+}
+
+func renameAccount(userID int64, alias string) (*models.Account, error) {
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+	if err := loadAccountsLocked(); err != nil {
+		return nil, err
+	}
+	index := findAccountLocked(userID)
+	if index < 0 {
+		return nil, apperror.New(apperror.CodeAccountNotFound, "account", strconv.FormatInt(userID, 10))
+	}
+
+	account := &accounts.Accounts[index]
+	account.Alias = strings.TrimSpace(alias)
+	if err := saveAccountsLocked(); err != nil {
+		return nil, err
+	}
+	result := *account
+	result.Active = account.ID == accounts.ActiveID
+	return &result, nil
+}
+
+// removeAccount forgets the account and deletes its token. Removing the active
+// account leaves no account selected.
+func removeAccount(userID int64) error {
+	accountsMu.Lock()
+	if err := loadAccountsLocked(); err != nil {
+		accountsMu.Unlock()
+		return err
+	}
+	index := findAccountLocked(userID)
+	if index < 0 {
+		accountsMu.Unlock()
+		return apperror.New(apperror.CodeAccountNotFound, "account", strconv.FormatInt(userID, 10))
+	}
+
+	wasActive := accounts.ActiveID == userID
+	accounts.Accounts = append(accounts.Accounts[:index], accounts.Accounts[index+1:]...)
+	if wasActive {
+		accounts.ActiveID = 0
+	}
+	err := saveAccountsLocked()
+	accountsMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := keyring.Delete(tokenService, accountTokenKey(userID)); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("remove account token from keyring: %w", err)
+	}
+	if wasActive {
+		notifyAccountChanged()
+	}
+	return nil
+}
+
+// signOutTokenKey marks the account whose token is stored under key as needing a new
+// login and deselects it if it is active. It reports whether key was the active key.
+func signOutTokenKey(key string) (bool, error) {
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+	if err := loadAccountsLocked(); err != nil {
+		return false, err
+	}
+
+	activeKey := oauthTokenKey
+	if accounts.ActiveID != 0 {
+		activeKey = accountTokenKey(accounts.ActiveID)
+	}
+	changed := false
+	for i := range accounts.Accounts {
+		if accountTokenKey(accounts.Accounts[i].ID) == key && accounts.Accounts[i].SignedIn {
+			accounts.Accounts[i].SignedIn = false
+			changed = true
+		}
+	}
+	active := key == activeKey
+	if active && accounts.ActiveID != 0 {
+		accounts.ActiveID = 0
+		changed = true
+	}
+	if !changed {
+		return active, nil
+	}
+	return active, saveAccountsLocked()
+}
+
+// deactivateAccount deselects the active account. With signOut the account is also
+// marked as needing a new login.
+func deactivateAccount(signOut bool) error {
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+	if err := loadAccountsLocked(); err != nil {
+		return err
+	}
+	if accounts.ActiveID == 0 {
+		return nil
+	}
+	if index := findAccountLocked(accounts.ActiveID); index >= 0 && signOut {
+		accounts.Accounts[index].SignedIn = false
+	}
+	accounts.ActiveID = 0
+	return saveAccountsLocked()
+}
+
+func findAccountLocked(userID int64) int {
+	for i, account := range accounts.Accounts {
+		if account.ID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+func accountsPath() (string, error) {
+	dataDir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, accountsFile), nil
+}
+
+func loadAccountsLocked() error {
+	if accountsLoaded {
+		return nil
+	}
+
+	path, err := accountsPath()
+	if err != nil {
+		return err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			accounts = accountIndex{}
+			accountsLoaded = true
+			return nil
+		}
+		return fmt.Errorf("read accounts: %w", err)
+	}
+
+	var index accountIndex
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return fmt.Errorf("decode accounts: %w", err)
+	}
+	accounts = index
+	accountsLoaded = true
+	return nil
+}
+
+func saveAccountsLocked() error {
+	path, err := accountsPath()
+	if err != nil {
+		return err
+	}
+	if err := ensureDirs(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	payload, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode accounts: %w", err)
+	}
+	if err := os.WriteFile(path, payload, 0o600); err != nil {
+		return fmt.Errorf("write accounts: %w", err)
+	}
+	return nil
+}
@@ -16,6 +16,8 @@ import (
 	"time"
 
 	"loliashizuku/backend/api"
+	"loliashizuku/backend/apperror"
+	"loliashizuku/backend/dnscheck"
 	"loliashizuku/backend/httpclient"
 	"loliashizuku/backend/models"
 )
@@ -25,10 +27,14 @@ const (
 	defaultHTTPTimeout      = 20 * time.Second
 	runnerLogMaxLines       = 300
 	runnerStopTimeout       = 3 * time.Second
+	dashboardSectionCount   = 5
 )
 
 type CenterService struct {
-	api *api.CenterAPI
+	api            *api.CenterAPI
+	requests       *requestTracker
+	appCtx         context.Context
+	domainVerifier *dnscheck.Verifier
 
 	runnerMu          sync.Mutex
 	runnerCmd         *exec.Cmd
@@ -36,30 +42,61 @@ type CenterService struct {
 	runnerStartedAt   time.Time
 	runnerTunnelName  string
 	runnerNodeAddress string
+	runnerNodeID      int64
 	runnerCommand     string
 	runnerLastError   string
 	runnerLogs        []string
 	runnerStopping    bool
+	runnerAccountID   int64
+	runnerAccountName string
 }
 
 func NewCenterService() *CenterService {
-	service := &CenterService{}
+	service := &CenterService{
+		requests:       newRequestTracker(),
+		domainVerifier: dnscheck.NewVerifier(dnscheck.NewResolver(os.Getenv("LOLIA_DNS_SERVER"))),
+	}
 
 	client := httpclient.New(httpclient.Options{
-		BaseURL:    centerAPIBaseURL(),
-		HTTPClient: &http.Client{Timeout: defaultHTTPTimeout},
+		BaseURL: centerAPIBaseURL(),
+		HTTPClient: httpclient.WrapClient(
+			&http.Client{Timeout: defaultHTTPTimeout, Transport: outboundTransport},
+			httpTrace.Middleware("center"),
+		),
+		Retry:       httpclient.DefaultRetryPolicy(),
+		Middlewares: []httpclient.Middleware{recordTokenKey()},
 		GetAccessToken: func(ctx context.Context) (string, error) {
 			return service.getValidAccessToken(ctx)
 		},
 		OnUnauthorized: func(ctx context.Context) error {
-			return ClearOAuthToken()
+			if _, ok := accessTokenOverride(ctx); ok {
+				// A token that is not stored yet was rejected; nothing to clear.
+				return nil
+			}
+			key, ok := requestTokenKey(ctx)
+			if !ok {
+				return nil
+			}
+			return clearOAuthTokenKey(key)
 		},
 	})
 
-	service.api = api.NewCenterAPI(client)
+	service.api = api.NewCenterAPI(client, api.NewResponseCache(centerCacheSnapshotPath()))
+	onAccountChanged(func() {
+		service.api.InvalidateCache()
+	})
+	setAccountIdentityResolver(service.resolveAccountIdentity)
 	return service
 }
 
+func centerCacheSnapshotPath() string {
+	dataDir, err := userDataDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dataDir, "cache", "center_api.json")
+}
+
 func centerAPIBaseURL() string {
 	baseURL := strings.TrimSpace(os.Getenv("LOLIA_CENTER_API_BASE_URL"))
 	if baseURL == "" {
@@ -69,10 +106,17 @@ func centerAPIBaseURL() string {
 }
 
 func (s *CenterService) getValidAccessToken(ctx context.Context) (string, error) {
+	if token, ok := accessTokenOverride(ctx); ok {
+		return token, nil
+	}
+
+	key := activeTokenKey()
+	setRequestTokenKey(ctx, key)
+
 	refreshCtx, cancel := context.WithTimeout(ctx, defaultHTTPTimeout)
 	defer cancel()
 
-	token, err := loadOrRefreshOAuthToken(refreshCtx)
+	token, err := loadOrRefreshOAuthToken(refreshCtx, key)
 	if err != nil {
 		return "", err
 	}
@@ -80,56 +124,113 @@ func (s *CenterService) getValidAccessToken(ctx context.Context) (string, error)
 	return token.AccessToken, nil
 }
 
-func (s *CenterService) GetDashboard() (*models.CenterDashboardData, error) {
-	ctx := context.Background()
-
-	user, err := s.api.GetUserInfo(ctx)
-	if err != nil {
-		return nil, err
-	}
-	traffic, err := s.api.GetUserTrafficStats(ctx)
-	if err != nil {
-		return nil, err
-	}
-	tunnelList, err := s.api.GetUserTunnels(ctx, 1, 20)
-	if err != nil {
-		return nil, err
-	}
-	version, err := s.api.GetClientVersion(ctx)
-	if err != nil {
-		return nil, err
-	}
-	homeStats, err := s.api.GetHomeStats(ctx)
-	if err != nil {
-		return nil, err
+// GetDashboard loads every dashboard section concurrently. A failing section is
+// reported in Errors instead of failing the whole dashboard; an error is returned
+// only when no section could be loaded.
+func (s *CenterService) GetDashboard(requestID string) (*models.CenterDashboardData, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	data := &models.CenterDashboardData{}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		errs     = map[string]string{}
+		firstErr error
+	)
+	load := func(section string, fetch func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fetch(); err != nil {
+				mu.Lock()
+				errs[section] = err.Error()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
 	}
 
-	data := &models.CenterDashboardData{
-		User:    *user,
-		Traffic: *traffic,
-		Tunnel: models.UserTunnelSummary{
+	load(models.DashboardSectionUser, func() error {
+		user, err := s.api.GetUserInfo(ctx)
+		if err != nil {
+			return err
+		}
+		data.User = *user
+		return nil
+	})
+	load(models.DashboardSectionTraffic, func() error {
+		traffic, err := s.api.GetUserTrafficStats(ctx)
+		if err != nil {
+			return err
+		}
+		data.Traffic = *traffic
+		return nil
+	})
+	load(models.DashboardSectionTunnels, func() error {
+		tunnelList, err := s.api.ListAllTunnels(ctx)
+		if err != nil {
+			return err
+		}
+		data.Tunnel = models.UserTunnelSummary{
 			Count: int64(len(tunnelList.List)),
 			Total: tunnelList.Total,
-		},
-		Tunnels:   tunnelList.List,
-		App:       *version,
-		HomeStats: *homeStats,
+		}
+		data.Tunnels = tunnelList.List
+		return nil
+	})
+	load(models.DashboardSectionApp, func() error {
+		version, err := s.api.GetClientVersion(ctx)
+		if err != nil {
+			return err
+		}
+		data.App = *version
+		return nil
+	})
+	load(models.DashboardSectionHome, func() error {
+		homeStats, err := s.api.GetHomeStats(ctx)
+		if err != nil {
+			return err
+		}
+		data.HomeStats = *homeStats
+		return nil
+	})
+	wg.Wait()
+
+	if len(errs) == dashboardSectionCount {
+		return nil, firstErr
+	}
+	if len(errs) > 0 {
+		data.Errors = errs
 	}
 	return data, nil
 }
 
-func (s *CenterService) GetTunnelsOverview(page, limit, days int) (*models.TunnelOverviewData, error) {
-	ctx := context.Background()
+// GetTunnelsOverview filters and sorts the full tunnel list, joined with traffic of the
+// last query.Days days, and returns the requested page of the result. Missing traffic
+// fails the call when sorting by traffic and is flagged in the result otherwise.
+func (s *CenterService) GetTunnelsOverview(requestID string, query models.TunnelQuery) (*models.TunnelOverviewData, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	query = normalizeTunnelQuery(query)
 
-	tunnelList, err := s.api.GetUserTunnels(ctx, page, limit)
+	tunnelList, err := s.api.ListAllTunnels(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	trafficByName := map[string]models.TrafficTunnelItem{}
-	if days > 0 {
-		traffic, trafficErr := s.api.GetTrafficTunnels(ctx, days)
-		if trafficErr == nil {
+	trafficUnavailable := false
+	if query.Days > 0 {
+		traffic, trafficErr := s.api.GetTrafficTunnels(ctx, query.Days)
+		if trafficErr != nil {
+			if query.SortBy == models.TunnelSortTraffic {
+				return nil, trafficErr
+			}
+			trafficUnavailable = true
+		} else {
 			for _, item := range traffic.Tunnels {
 				trafficByName[strings.TrimSpace(item.TunnelName)] = item
 			}
@@ -147,17 +248,19 @@ func (s *CenterService) GetTunnelsOverview(page, limit, days int) (*models.Tunne
 		enriched = append(enriched, current)
 	}
 
-	return &models.TunnelOverviewData{
-		List:      enriched,
-		Page:      tunnelList.Page,
-		Limit:     tunnelList.Limit,
-		Total:     tunnelList.Total,
-		TotalPage: tunnelList.TotalPage,
-	}, nil
+	filtered := filterTunnels(enriched, query)
+	sortTunnels(filtered, query.SortBy, query.SortDesc)
+	if query.All {
+		query.Page, query.Limit = 1, max(len(filtered), 1)
+	}
+	result := paginateTunnels(filtered, query.Page, query.Limit)
+	result.TrafficUnavailable = trafficUnavailable
+	return result, nil
 }
 
-func (s *CenterService) GetRunnerData(tunnelID int64) (*models.RunnerData, error) {
-	ctx := context.Background()
+func (s *CenterService) GetRunnerData(requestID string, tunnelID int64) (*models.RunnerData, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
 
 	version, err := s.api.GetClientVersion(ctx)
 	if err != nil {
@@ -167,7 +270,7 @@ func (s *CenterService) GetRunnerData(tunnelID int64) (*models.RunnerData, error
 	if err != nil {
 		return nil, err
 	}
-	tunnels, err := s.api.GetUserTunnels(ctx, 1, 100)
+	tunnels, err := s.api.ListAllTunnels(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -195,23 +298,23 @@ func (s *CenterService) GetRunnerData(tunnelID int64) (*models.RunnerData, error
 	}, nil
 }
 
-func (s *CenterService) StartRunner(tunnelName string) (*models.RunnerRuntimeStatus, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultHTTPTimeout)
-	defer cancel()
+func (s *CenterService) StartRunner(requestID string, tunnelName string) (*models.RunnerRuntimeStatus, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
 
 	selectedTunnelName := strings.TrimSpace(tunnelName)
 	if selectedTunnelName == "" {
-		tunnels, err := s.api.GetUserTunnels(ctx, 1, 100)
+		tunnels, err := s.api.ListAllTunnels(ctx)
 		if err != nil {
 			return nil, err
 		}
 		if len(tunnels.List) == 0 {
-			return nil, fmt.Errorf("当前账号暂无隧道，无法启动 frpc")
+			return nil, apperror.New(apperror.CodeNoTunnel)
 		}
 		selectedTunnelName = strings.TrimSpace(tunnels.List[0].Name)
 	}
 	if selectedTunnelName == "" {
-		return nil, fmt.Errorf("无效的隧道名称")
+		return nil, apperror.New(apperror.CodeInvalidTunnel, "tunnel", tunnelName)
 	}
 
 	tunnelDetail, err := s.api.GetTunnelDetail(ctx, selectedTunnelName)
@@ -219,58 +322,61 @@ func (s *CenterService) StartRunner(tunnelName string) (*models.RunnerRuntimeSta
 		return nil, err
 	}
 	if tunnelDetail == nil {
-		return nil, fmt.Errorf("获取隧道详情失败：%s", selectedTunnelName)
+		return nil, apperror.New(apperror.CodeInvalidTunnel, "tunnel", selectedTunnelName).WithDetail("empty tunnel detail")
 	}
 	if tunnelDetail.ID <= 0 {
-		return nil, fmt.Errorf("隧道详情缺少有效 id：%s", selectedTunnelName)
+		return nil, apperror.New(apperror.CodeInvalidTunnel, "tunnel", selectedTunnelName).WithDetail("missing tunnel id")
 	}
 
 	token := strings.TrimSpace(tunnelDetail.TunnelToken)
 	if token == "" {
-		return nil, fmt.Errorf("隧道详情未返回 tunnel_token：%s", selectedTunnelName)
+		return nil, apperror.New(apperror.CodeInvalidTunnel, "tunnel", selectedTunnelName).WithDetail("missing tunnel_token")
 	}
 	tokenArg := fmt.Sprintf("%d:%s", tunnelDetail.ID, token)
 
 	binaryPath, err := resolveLocalFrpcBinaryPath()
 	if err != nil {
-		return nil, err
+		return nil, apperror.Wrap(apperror.CodeStorage, err)
 	}
 	exists, err := fileExistsForRunner(binaryPath)
 	if err != nil {
-		return nil, err
+		return nil, apperror.Wrap(apperror.CodeStorage, err)
 	}
 	if !exists {
-		return nil, fmt.Errorf("frpc 未安装，请先在设置页面安装: %s", binaryPath)
+		return nil, apperror.New(apperror.CodeNotInstalled).WithDetail(binaryPath)
 	}
 
 	s.runnerMu.Lock()
 	if s.isRunnerRunningLocked() {
 		status := s.buildRunnerStatusLocked()
 		s.runnerMu.Unlock()
-		return status, fmt.Errorf("runner 已在运行中")
+		return status, apperror.New(apperror.CodeRunnerBusy)
 	}
 
 	runCtx, runCancel := context.WithCancel(context.Background())
 	cmd := exec.CommandContext(runCtx, binaryPath, "-t", tokenArg)
 	configureBackgroundProcess(cmd)
+	if env := frpcProxyEnv(); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		runCancel()
 		s.runnerMu.Unlock()
-		return nil, fmt.Errorf("打开 frpc stdout 失败: %w", err)
+		return nil, apperror.Wrap(apperror.CodeRunnerStart, fmt.Errorf("打开 frpc stdout 失败: %w", err))
 	}
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		runCancel()
 		s.runnerMu.Unlock()
-		return nil, fmt.Errorf("打开 frpc stderr 失败: %w", err)
+		return nil, apperror.Wrap(apperror.CodeRunnerStart, fmt.Errorf("打开 frpc stderr 失败: %w", err))
 	}
 
 	if err := cmd.Start(); err != nil {
 		runCancel()
 		s.runnerMu.Unlock()
-		return nil, fmt.Errorf("启动 frpc 失败: %w", err)
+		return nil, apperror.Wrap(apperror.CodeRunnerStart, fmt.Errorf("启动 frpc 失败: %w", err))
 	}
 
 	s.runnerCmd = cmd
@@ -278,11 +384,16 @@ func (s *CenterService) StartRunner(tunnelName string) (*models.RunnerRuntimeSta
 	s.runnerStartedAt = time.Now().UTC()
 	s.runnerTunnelName = tunnelDetail.Name
 	s.runnerNodeAddress = tunnelDetail.NodeAddress
+	s.runnerNodeID = tunnelDetail.NodeID
 	s.runnerCommand = fmt.Sprintf("%s -t %s", binaryPath, maskRunnerTokenArg(tokenArg))
 	s.runnerLastError = ""
 	s.runnerLogs = []string{
 		fmt.Sprintf("[runner] started: pid=%d", cmd.Process.Pid),
 	}
+	s.runnerAccountID, s.runnerAccountName = 0, ""
+	if account := activeAccount(); account != nil {
+		s.runnerAccountID, s.runnerAccountName = account.ID, account.DisplayName()
+	}
 	s.runnerStopping = false
 	status := s.buildRunnerStatusLocked()
 	s.runnerMu.Unlock()
@@ -340,43 +451,90 @@ func (s *CenterService) GetRunnerRuntimeStatus() (*models.RunnerRuntimeStatus, e
 	return s.buildRunnerStatusLocked(), nil
 }
 
-func (s *CenterService) GetUserInfo() (*models.UserInfoData, error) {
-	return s.api.GetUserInfo(context.Background())
+func (s *CenterService) GetUserInfo(requestID string) (*models.UserInfoData, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.api.GetUserInfo(ctx)
+}
+
+func (s *CenterService) GetUserTrafficStats(requestID string) (*models.UserTrafficData, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.api.GetUserTrafficStats(ctx)
 }
 
-func (s *CenterService) GetUserTrafficStats() (*models.UserTrafficData, error) {
-	return s.api.GetUserTrafficStats(context.Background())
+func (s *CenterService) GetUserTunnels(requestID string, page, limit int) (*models.TunnelListData, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.api.GetUserTunnels(ctx, page, limit)
 }
 
-func (s *CenterService) GetUserTunnels(page, limit int) (*models.TunnelListData, error) {
-	return s.api.GetUserTunnels(context.Background(), page, limit)
+func (s *CenterService) ListAllTunnels(requestID string) (*models.TunnelListData, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.api.ListAllTunnels(ctx)
 }
 
-func (s *CenterService) GetTrafficTunnels(days int) (*models.TrafficTunnelData, error) {
-	return s.api.GetTrafficTunnels(context.Background(), days)
+func (s *CenterService) GetTrafficTunnels(requestID string, days int) (*models.TrafficTunnelData, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.api.GetTrafficTunnels(ctx, days)
 }
 
-func (s *CenterService) GetTrafficDaily(days int) (*models.DailyTrafficResponse, error) {
+func (s *CenterService) GetTrafficDaily(requestID string, days int) (*models.DailyTrafficResponse, error) {
 	if days <= 0 {
 		days = 7
 	}
-	return s.api.GetTrafficDaily(context.Background(), days)
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.api.GetTrafficDaily(ctx, days)
+}
+
+func (s *CenterService) GetNodes(requestID string) (*models.NodeListData, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.api.GetNodes(ctx)
+}
+
+func (s *CenterService) GetFrpcConfig(requestID string, tunnel string) (*models.FrpcConfigData, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.api.GetFrpcConfig(ctx, tunnel)
 }
 
-func (s *CenterService) GetNodes() (*models.NodeListData, error) {
-	return s.api.GetNodes(context.Background())
+func (s *CenterService) GetClientVersion(requestID string) (*models.AppVersionInfo, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.api.GetClientVersion(ctx)
 }
 
-func (s *CenterService) GetFrpcConfig(tunnel string) (*models.FrpcConfigData, error) {
-	return s.api.GetFrpcConfig(context.Background(), tunnel)
+func (s *CenterService) GetHomeStats(requestID string) (*models.HomeStatsData, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.api.GetHomeStats(ctx)
 }
 
-func (s *CenterService) GetClientVersion() (*models.AppVersionInfo, error) {
-	return s.api.GetClientVersion(context.Background())
+// Startup binds in-flight Center API calls to the app lifetime.
+func (s *CenterService) Startup(ctx context.Context) {
+	s.appCtx = ctx
+	s.requests.start(ctx)
+	go s.adoptUnassignedToken()
 }
 
-func (s *CenterService) GetHomeStats() (*models.HomeStatsData, error) {
-	return s.api.GetHomeStats(context.Background())
+// Shutdown cancels every in-flight Center API call.
+func (s *CenterService) Shutdown() {
+	s.requests.shutdown()
+}
+
+// CancelRequest aborts the in-flight call started with requestID.
+// It reports whether such a call was found.
+func (s *CenterService) CancelRequest(requestID string) bool {
+	return s.requests.cancelRequest(requestID)
+}
+
+// RefreshCache drops all cached Center API responses so the next reads hit the network.
+func (s *CenterService) RefreshCache() {
+	s.api.InvalidateCache()
 }
 
 func (s *CenterService) isRunnerRunningLocked() bool {
@@ -396,6 +554,9 @@ func (s *CenterService) buildRunnerStatusLocked() *models.RunnerRuntimeStatus {
 		LastError:   s.runnerLastError,
 		TunnelName:  s.runnerTunnelName,
 		NodeAddress: s.runnerNodeAddress,
+		NodeID:      s.runnerNodeID,
+		AccountID:   s.runnerAccountID,
+		AccountName: s.runnerAccountName,
 	}
 
 	if !s.runnerStartedAt.IsZero() {
@@ -450,6 +611,13 @@ func (s *CenterService) consumeRunnerOutput(reader io.Reader) {
 	}
 }
 
+// noteRunnerEvent adds a line to the runner log on behalf of another service.
+func (s *CenterService) noteRunnerEvent(line string) {
+	s.runnerMu.Lock()
+	s.appendRunnerLogLocked(line)
+	s.runnerMu.Unlock()
+}
+
 func (s *CenterService) appendRunnerLogLocked(line string) {
 	s.runnerLogs = append(s.runnerLogs, line)
 	if len(s.runnerLogs) > runnerLogMaxLines {
@@ -458,15 +626,13 @@ func (s *CenterService) appendRunnerLogLocked(line string) {
 }
 
 func resolveLocalFrpcBinaryPath() (string, error) {
-	configDir, err := os.UserConfigDir()
+	dataDir, err := userDataDir()
 	if err != nil {
 		return "", fmt.Errorf("获取配置目录失败: %w", err)
 	}
 
 	path := filepath.Join(
-		configDir,
-		"LoliaShizuku",
-		"userdata",
+		dataDir,
 		"frpc",
 		"bin",
 		runnerFrpcBinaryName(),
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"loliashizuku/backend/api"
+	"loliashizuku/backend/config"
+	"loliashizuku/backend/models"
+)
+
+const (
+	checkInPollInterval  = 30 * time.Minute
+	checkInInitialDelay  = 10 * time.Second
+	checkInHistoryLimit  = 90
+	checkInHistoryFile   = "checkin_history.json"
+	checkInRecordDateFmt = "2006-01-02"
+)
+
+// CheckInService performs the daily check-in, optionally automatically once per day
+// while the app is open, and keeps a local history of the results.
+type CheckInService struct {
+	center        *CenterService
+	configManager *config.Manager
+
+	mu       sync.Mutex
+	loopOnce sync.Once
+}
+
+// NewCheckInService creates a new CheckInService.
+func NewCheckInService(center *CenterService, configManager *config.Manager) *CheckInService {
+	return &CheckInService{
+		center:        center,
+		configManager: configManager,
+	}
+}
+
+// Start launches the automatic check-in loop. It stops when ctx is done.
+func (s *CheckInService) Start(ctx context.Context) {
+	s.loopOnce.Do(func() {
+		go s.loopAutoCheckIn(ctx)
+	})
+}
+
+// CheckIn performs today's check-in and records the result.
+func (s *CheckInService) CheckIn(requestID string) (*models.CheckInResult, error) {
+	ctx, done := s.center.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.checkIn(ctx, false)
+}
+
+// GetCheckInHistory returns locally recorded check-ins, newest first.
+func (s *CheckInService) GetCheckInHistory() ([]models.CheckInRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return loadCheckInHistory()
+}
+
+func (s *CheckInService) checkIn(ctx context.Context, automatic bool) (*models.CheckInResult, error) {
+	record := models.CheckInRecord{
+		Date:      time.Now().Format(checkInRecordDateFmt),
+		CheckedAt: time.Now().UTC().Format(time.RFC3339),
+		Automatic: automatic,
+	}
+	if user, err := s.center.api.GetUserInfo(ctx); err == nil {
+		record.UserID = user.ID
+	}
+
+	result, err := s.center.api.CheckIn(ctx)
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.Success = true
+		record.Reward = result.Reward
+		record.TrafficLimit = result.TrafficLimit
+	}
+
+	if saveErr := s.appendRecord(record); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *CheckInService) loopAutoCheckIn(ctx context.Context) {
+	timer := time.NewTimer(checkInInitialDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		s.autoCheckInOnce(ctx)
+		timer.Reset(checkInPollInterval)
+	}
+}
+
+func (s *CheckInService) autoCheckInOnce(ctx context.Context) {
+	if s.configManager == nil || !s.configManager.GetConfig().App.AutoCheckIn {
+		return
+	}
+	if has, err := HasOAuthToken(); err != nil || !has {
+		return
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+	defer cancel()
+
+	user, err := s.center.api.GetUserInfo(api.BypassCache(reqCtx))
+	if err != nil {
+		return
+	}
+
+	today := time.Now().Format(checkInRecordDateFmt)
+	if s.hasSuccessfulRecord(today, user.ID) {
+		return
+	}
+	if user.TodayChecked {
+		_ = s.appendRecord(models.CheckInRecord{
+			Date:      today,
+			CheckedAt: time.Now().UTC().Format(time.RFC3339),
+			UserID:    user.ID,
+			Automatic: true,
+			Success:   true,
+		})
+		return
+	}
+
+	_, _ = s.checkIn(reqCtx, true)
+}
+
+func (s *CheckInService) hasSuccessfulRecord(date string, userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history, err := loadCheckInHistory()
+	if err != nil {
+		return false
+	}
+	for _, record := range history {
+		if record.Date == date && record.UserID == userID && record.Success {
+			return true
+		}
+	}
+	return false
+}
+
+// appendRecord adds record to the history. A failed attempt of the same day and user is
+// updated in place instead, so retries every poll keep at most one failure per day.
+func (s *CheckInService) appendRecord(record models.CheckInRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history, err := loadCheckInHistory()
+	if err != nil {
+		history = nil
+	}
+	replaced := false
+	for i, existing := range history {
+		if existing.Date == record.Date && existing.UserID == record.UserID && !existing.Success {
+			history[i] = record
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		history = append([]models.CheckInRecord{record}, history...)
+	}
+	if len(history) > checkInHistoryLimit {
+		history = history[:checkInHistoryLimit]
+	}
+	return saveCheckInHistory(history)
+}
+
+func checkInHistoryPath() (string, error) {
+	dataDir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, checkInHistoryFile), nil
+}
+
+func loadCheckInHistory() ([]models.CheckInRecord, error) {
+	path, err := checkInHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []models.CheckInRecord{}, nil
+		}
+		return nil, fmt.Errorf("read check-in history: %w", err)
+	}
+
+	var history []models.CheckInRecord
+	if err := json.Unmarshal(raw, &history); err != nil {
+		return nil, fmt.Errorf("decode check-in history: %w", err)
+	}
+	return history, nil
+}
+
+func saveCheckInHistory(history []models.CheckInRecord) error {
+	path, err := checkInHistoryPath()
+	if err != nil {
+		return err
+	}
+	if err := ensureDirs(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	payload, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode check-in history: %w", err)
+	}
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		return fmt.Errorf("write check-in history: %w", err)
+	}
+	return nil
+}
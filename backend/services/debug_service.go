@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+
+	"loliashizuku/backend/config"
+	"loliashizuku/backend/debugtrace"
+	"loliashizuku/backend/httpclient"
+	"loliashizuku/backend/models"
+
+	"golang.org/x/oauth2"
+)
+
+// httpTrace records Center, GitHub and OAuth traffic while AdvancedConfig.DebugMode is on.
+var httpTrace = debugtrace.NewRecorder(httpTraceFilePath)
+
+func httpTraceFilePath() (string, error) {
+	dataDir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "logs", "http_trace.log"), nil
+}
+
+// withOAuthHTTPClient makes oauth2 token exchanges and refreshes made with ctx go
+// through the outbound proxy and the traced client.
+func withOAuthHTTPClient(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, oauthHTTPClient())
+}
+
+func oauthHTTPClient() *http.Client {
+	return httpclient.WrapClient(&http.Client{Timeout: oauthTokenTimeout, Transport: outboundTransport}, httpTrace.Middleware("oauth"))
+}
+
+// DebugService exposes the HTTP debug trace to the frontend.
+type DebugService struct{}
+
+// NewDebugService creates a DebugService and turns tracing on whenever debug mode is enabled.
+func NewDebugService(configManager *config.Manager) *DebugService {
+	httpTrace.SetEnabled(func() bool {
+		cfg := configManager.GetConfig()
+		return cfg != nil && cfg.Advanced.DebugMode
+	})
+	return &DebugService{}
+}
+
+// GetHTTPTraceStatus reports whether tracing is on and where the trace file lives.
+func (s *DebugService) GetHTTPTraceStatus() (*models.HTTPTraceStatus, error) {
+	path, err := httpTrace.FilePath()
+	if err != nil {
+		return nil, err
+	}
+	return &models.HTTPTraceStatus{
+		Enabled:  httpTrace.Enabled(),
+		FilePath: path,
+		Entries:  httpTrace.Len(),
+	}, nil
+}
+
+// GetHTTPTrace returns up to limit recent requests, newest first. source filters by
+// "center", "github" or "oauth"; empty returns all.
+func (s *DebugService) GetHTTPTrace(limit int, source string) []models.HTTPTraceEntry {
+	return httpTrace.Entries(limit, source)
+}
+
+// ClearHTTPTrace drops recorded requests and deletes the trace files.
+func (s *DebugService) ClearHTTPTrace() error {
+	return httpTrace.Clear()
+}
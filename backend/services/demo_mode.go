@@ -0,0 +1,71 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"loliashizuku/backend/config"
+	"loliashizuku/backend/demo"
+
+	"github.com/zalando/go-keyring"
+)
+
+var (
+	demoModeEnabled bool
+	demoRoot        string
+)
+
+// DemoModeRequested reports whether the app was started with --demo or LOLIA_DEMO_MODE=1.
+func DemoModeRequested(args []string) bool {
+	for _, arg := range args {
+		if arg == "--demo" || arg == "-demo" {
+			return true
+		}
+	}
+	switch strings.TrimSpace(strings.ToLower(os.Getenv("LOLIA_DEMO_MODE"))) {
+	case "1", "true", "yes", "on":
+		return true
+	default:
+		return false
+	}
+}
+
+// EnableDemoMode points the Center API, OAuth and frpc release endpoints at the demo
+// server. Tokens are kept in memory and userdata and config.json move to a separate
+// directory, so the real account and settings are left untouched. It must run before the services are created.
+func EnableDemoMode(server *demo.Server) error {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return fmt.Errorf("get config dir: %w", err)
+	}
+
+	overrides := map[string]string{
+		"LOLIA_CENTER_API_BASE_URL":   server.CenterAPIBaseURL(),
+		"LOLIA_OAUTH_AUTHORIZE_URL":   server.OAuthAuthorizeURL(),
+		"LOLIA_OAUTH_TOKEN_URL":       server.OAuthTokenURL(),
+		"LOLIA_OAUTH_DEVICE_AUTH_URL": server.OAuthDeviceAuthURL(),
+		"LOLIA_GITHUB_API_BASE_URL":   server.GitHubAPIBaseURL(),
+	}
+	for key, value := range overrides {
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("set %s: %w", key, err)
+		}
+	}
+
+	keyring.MockInit()
+	demoRoot = filepath.Join(configDir, "LoliaShizuku", "demo")
+	userDataRoot = filepath.Join(demoRoot, "userdata")
+	demoModeEnabled = true
+	return nil
+}
+
+// NewConfigManager returns the config manager for this run; in demo mode it keeps
+// config.json in the demo directory.
+func NewConfigManager() *config.Manager {
+	if demoModeEnabled {
+		return config.NewManagerAt(demoRoot)
+	}
+	return config.NewManager()
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"loliashizuku/backend/dnscheck"
+	"loliashizuku/backend/models"
+)
+
+const domainCheckConcurrency = 8
+
+// VerifyTunnelDomain checks the DNS records of a tunnel's custom domains against its node.
+func (s *CenterService) VerifyTunnelDomain(requestID string, tunnelName string) ([]models.DomainCheckResult, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+
+	detail, err := s.api.GetTunnelDetail(ctx, tunnelName)
+	if err != nil {
+		return nil, err
+	}
+	if detail == nil {
+		return []models.DomainCheckResult{}, nil
+	}
+	nodes, err := s.nodeAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tunnel := models.TunnelItem{
+		CustomDomain: detail.CustomDomain,
+		Name:         detail.Name,
+		NodeID:       detail.NodeID,
+		Type:         detail.Type,
+	}
+	node := nodes[detail.NodeID]
+	if node.IPAddress == "" {
+		node = models.NodeItem{ID: detail.NodeID, Name: detail.NodeName, IPAddress: detail.NodeAddress}
+	}
+	return s.checkTunnelDomains(ctx, []models.TunnelItem{tunnel}, map[int64]models.NodeItem{detail.NodeID: node}), nil
+}
+
+// VerifyTunnelDomains checks every HTTP/HTTPS tunnel that has a custom domain.
+func (s *CenterService) VerifyTunnelDomains(requestID string) ([]models.DomainCheckResult, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+
+	tunnels, err := s.api.ListAllTunnels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.nodeAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	withDomain := make([]models.TunnelItem, 0, len(tunnels.List))
+	for _, tunnel := range tunnels.List {
+		if isHTTPTunnel(tunnel.Type) && strings.TrimSpace(tunnel.CustomDomain) != "" {
+			withDomain = append(withDomain, tunnel)
+		}
+	}
+	return s.checkTunnelDomains(ctx, withDomain, nodes), nil
+}
+
+func (s *CenterService) nodeAddresses(ctx context.Context) (map[int64]models.NodeItem, error) {
+	nodes, err := s.api.GetNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]models.NodeItem, len(nodes.Nodes))
+	for _, node := range nodes.Nodes {
+		byID[node.ID] = node
+	}
+	return byID, nil
+}
+
+func (s *CenterService) checkTunnelDomains(ctx context.Context, tunnels []models.TunnelItem, nodes map[int64]models.NodeItem) []models.DomainCheckResult {
+	type job struct {
+		tunnel models.TunnelItem
+		domain string
+	}
+	var jobs []job
+	for _, tunnel := range tunnels {
+		for _, domain := range splitCustomDomains(tunnel.CustomDomain) {
+			jobs = append(jobs, job{tunnel: tunnel, domain: domain})
+		}
+	}
+
+	results := make([]models.DomainCheckResult, len(jobs))
+	sem := make(chan struct{}, domainCheckConcurrency)
+	var wg sync.WaitGroup
+	for i, item := range jobs {
+		node := nodes[item.tunnel.NodeID]
+		if !isHTTPTunnel(item.tunnel.Type) {
+			results[i] = models.DomainCheckResult{
+				Domain:      item.domain,
+				Status:      models.DomainCheckSkipped,
+				CheckedAt:   time.Now().UTC().Format(time.RFC3339),
+				ExpectedIPs: []string{},
+				IPv4:        []string{},
+				IPv6:        []string{},
+				Issues: []models.DomainCheckIssue{{
+					Code:    "not_http",
+					Message: "只有 HTTP/HTTPS 隧道使用自定义域名",
+				}},
+			}
+		} else {
+			wg.Add(1)
+			go func(i int, domain string) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				results[i] = s.domainVerifier.Verify(ctx, dnscheck.Target{
+					Domain:      domain,
+					NodeAddress: node.IPAddress,
+				})
+			}(i, item.domain)
+		}
+	}
+	wg.Wait()
+
+	for i, item := range jobs {
+		node := nodes[item.tunnel.NodeID]
+		results[i].TunnelName = item.tunnel.Name
+		results[i].TunnelType = item.tunnel.Type
+		results[i].NodeID = item.tunnel.NodeID
+		results[i].NodeName = node.Name
+	}
+	return results
+}
+
+func isHTTPTunnel(tunnelType string) bool {
+	switch strings.ToLower(strings.TrimSpace(tunnelType)) {
+	case "http", "https":
+		return true
+	default:
+		return false
+	}
+}
+
+func splitCustomDomains(raw string) []string {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\n' || r == '\t'
+	})
+	domains := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if domain := strings.TrimSpace(field); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"loliashizuku/backend/apperror"
+	"loliashizuku/backend/frpcconf"
+	"loliashizuku/backend/models"
+	"loliashizuku/backend/netproxy"
+)
+
+const frpcVerifyTimeout = 10 * time.Second
+
+// GenerateFrpcConfig renders standalone frpc TOML configs for the given tunnels so they
+// can run on machines without this client. Configs contain tunnel tokens.
+func (s *CenterService) GenerateFrpcConfig(requestID string, options models.FrpcConfigOptions) (*models.FrpcConfigBundle, error) {
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+
+	names := make([]string, 0, len(options.TunnelNames))
+	for _, name := range options.TunnelNames {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, apperror.New(apperror.CodeInvalidArgument).WithDetail("未选择隧道")
+	}
+
+	nodes, err := s.nodeAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tunnels := make([]frpcconf.Tunnel, 0, len(names))
+	for _, name := range names {
+		detail, err := s.api.GetTunnelDetail(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if detail == nil {
+			return nil, apperror.New(apperror.CodeInvalidTunnel, "tunnel", name)
+		}
+		tunnels = append(tunnels, frpcconf.Tunnel{Detail: *detail, Node: nodes[detail.NodeID]})
+	}
+
+	var accountToken string
+	if options.Group {
+		if user, err := s.api.GetUserInfo(ctx); err == nil {
+			accountToken = user.TunnelToken
+		}
+	}
+	files, err := frpcconf.Generate(tunnels, frpcconf.Options{
+		Group:        options.Group,
+		AccountToken: accountToken,
+		ProxyURL: func(serverAddr string, serverPort int64) string {
+			return netproxy.FrpcProxyURL(currentProxyConfig(), serverAddr, serverPort)
+		},
+	})
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeInvalidArgument, err)
+	}
+
+	bundle := &models.FrpcConfigBundle{Configs: make([]models.GeneratedFrpcConfig, 0, len(files))}
+	binaryPath := ""
+	if options.Verify {
+		binaryPath, bundle.VerifyNote = installedFrpcForVerify()
+	} else {
+		bundle.VerifyNote = "未请求校验"
+	}
+
+	outputDir := strings.TrimSpace(options.OutputDir)
+	if outputDir != "" {
+		if err := ensureDirs(outputDir); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range files {
+		config := models.GeneratedFrpcConfig{
+			FileName:     file.Name,
+			NodeID:       file.NodeID,
+			NodeName:     file.NodeName,
+			ServerAddr:   file.ServerAddr,
+			ServerPort:   file.ServerPort,
+			Tunnels:      file.Tunnels,
+			Content:      string(file.Content),
+			VerifyStatus: models.FrpcVerifySkipped,
+		}
+		if binaryPath != "" {
+			config.VerifyStatus, config.VerifyOutput = verifyFrpcConfig(ctx, binaryPath, file.Content)
+		}
+		if outputDir != "" {
+			config.Path = filepath.Join(outputDir, file.Name)
+			if err := os.WriteFile(config.Path, file.Content, 0o600); err != nil {
+				return nil, fmt.Errorf("write frpc config: %w", err)
+			}
+		}
+		bundle.Configs = append(bundle.Configs, config)
+	}
+	return bundle, nil
+}
+
+// installedFrpcForVerify returns the frpc binary to verify with, or a note on why
+// verification is skipped.
+func installedFrpcForVerify() (string, string) {
+	binaryPath, err := resolveLocalFrpcBinaryPath()
+	if err != nil {
+		return "", err.Error()
+	}
+	exists, err := fileExistsForRunner(binaryPath)
+	if err != nil {
+		return "", err.Error()
+	}
+	if !exists {
+		return "", "frpc 未安装，已跳过校验"
+	}
+	return binaryPath, ""
+}
+
+func verifyFrpcConfig(ctx context.Context, binaryPath string, content []byte) (string, string) {
+	tempFile, err := os.CreateTemp("", "frpc-verify-*.toml")
+	if err != nil {
+		return models.FrpcVerifyFailed, fmt.Sprintf("create temp config: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return models.FrpcVerifyFailed, fmt.Sprintf("write temp config: %v", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return models.FrpcVerifyFailed, fmt.Sprintf("write temp config: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, frpcVerifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, binaryPath, "verify", "-c", tempFile.Name())
+	configureBackgroundProcess(cmd)
+	output, err := cmd.CombinedOutput()
+	text := strings.TrimSpace(strings.ReplaceAll(string(output), tempFile.Name(), "<config>"))
+	if err != nil {
+		if text == "" {
+			text = err.Error()
+		}
+		return models.FrpcVerifyFailed, text
+	}
+	return models.FrpcVerifyOK, text
+}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"loliashizuku/backend/api"
+	"loliashizuku/backend/apperror"
 	"loliashizuku/backend/httpclient"
 	"loliashizuku/backend/models"
 )
@@ -48,6 +49,7 @@ type frpcUserSettings struct {
 
 type FrpcService struct {
 	releaseAPI *api.GitHubReleaseAPI
+	etagStore  *httpclient.ETagStore
 	httpClient *http.Client
 	repoOwner  string
 	repoName   string
@@ -57,7 +59,11 @@ type FrpcService struct {
 }
 
 func NewFrpcService() *FrpcService {
-	client := &http.Client{Timeout: defaultFrpcInstallTimeout}
+	userAgent := httpclient.ResolveUserAgent("")
+	client := httpclient.WrapClient(
+		&http.Client{Timeout: defaultFrpcInstallTimeout, Transport: outboundTransport},
+		httpclient.Headers(map[string]string{"User-Agent": userAgent}),
+	)
 
 	repoOwner := strings.TrimSpace(os.Getenv("LOLIA_FRPC_REPO_OWNER"))
 	if repoOwner == "" {
@@ -68,8 +74,26 @@ func NewFrpcService() *FrpcService {
 		repoName = defaultFrpcRepoName
 	}
 
+	// Release lookups are conditional on the cached ETag; 304s do not count against
+	// GitHub's rate limit. The optional token raises the limit from 60 to 5000 per hour.
+	etagStore := httpclient.NewETagStore(githubCacheSnapshotPath())
+	releaseAPI := api.NewGitHubReleaseAPI(
+		client,
+		userAgent,
+		httpclient.ETagCache(etagStore),
+		httpclient.BearerAuth(func(context.Context) (string, error) {
+			// The token is optional; an unreadable keyring falls back to anonymous requests.
+			token, _ := LoadGitHubToken()
+			return token, nil
+		}),
+		httpclient.Retry(*httpclient.DefaultRetryPolicy(), nil),
+		httpTrace.Middleware("github"),
+	)
+	releaseAPI.SetBaseURL(os.Getenv("LOLIA_GITHUB_API_BASE_URL"))
+
 	return &FrpcService{
-		releaseAPI: api.NewGitHubReleaseAPI(client, httpclient.ResolveUserAgent("")),
+		releaseAPI: releaseAPI,
+		etagStore:  etagStore,
 		httpClient: client,
 		repoOwner:  repoOwner,
 		repoName:   repoName,
@@ -110,10 +134,12 @@ func (s *FrpcService) InstallOrUpdateFrpc() (*models.FrpcInstallResult, error) {
 
 	expectedSHA256 := strings.ToLower(strings.TrimSpace(latest.Asset.SHA256))
 	if expectedSHA256 == "" {
-		return nil, fmt.Errorf("release asset digest is empty: %s", latest.Asset.Name)
+		return nil, apperror.New(apperror.CodeInstallFailed).
+			WithDetail(fmt.Sprintf("release asset digest is empty: %s", latest.Asset.Name))
 	}
 	if downloadedSHA256 != expectedSHA256 {
-		return nil, fmt.Errorf("sha256 mismatch for %s: expected=%s actual=%s", latest.Asset.Name, expectedSHA256, downloadedSHA256)
+		return nil, apperror.New(apperror.CodeChecksumMismatch, "name", latest.Asset.Name).
+			WithDetail(fmt.Sprintf("expected=%s actual=%s", expectedSHA256, downloadedSHA256))
 	}
 
 	binaryName := filepath.Base(paths.BinaryPath)
@@ -164,14 +190,14 @@ func (s *FrpcService) CancelInstallOrUpdateFrpc() error {
 func (s *FrpcService) RemoveFrpc() error {
 	paths, err := s.paths()
 	if err != nil {
-		return err
+		return apperror.Wrap(apperror.CodeStorage, err)
 	}
 
 	if err := removeIfExists(paths.BinaryPath); err != nil {
-		return err
+		return apperror.Wrap(apperror.CodeStorage, err)
 	}
 	if err := removeIfExists(paths.StatePath); err != nil {
-		return err
+		return apperror.Wrap(apperror.CodeStorage, err)
 	}
 	return nil
 }
@@ -179,7 +205,7 @@ func (s *FrpcService) RemoveFrpc() error {
 func (s *FrpcService) GetGitHubMirrorURL() (string, error) {
 	settings, err := s.loadUserSettings()
 	if err != nil {
-		return "", err
+		return "", apperror.Wrap(apperror.CodeStorage, err)
 	}
 	return strings.TrimSpace(settings.GitHubMirrorURL), nil
 }
@@ -187,15 +213,70 @@ func (s *FrpcService) GetGitHubMirrorURL() (string, error) {
 func (s *FrpcService) SetGitHubMirrorURL(rawURL string) error {
 	mirrorURL, err := normalizeMirrorURL(rawURL)
 	if err != nil {
-		return err
+		return apperror.Wrap(apperror.CodeInvalidArgument, err)
 	}
 
 	settings, err := s.loadUserSettings()
 	if err != nil {
-		return err
+		return apperror.Wrap(apperror.CodeStorage, err)
 	}
 	settings.GitHubMirrorURL = mirrorURL
-	return s.saveUserSettings(settings)
+	if err := s.saveUserSettings(settings); err != nil {
+		return apperror.Wrap(apperror.CodeStorage, err)
+	}
+	return nil
+}
+
+// HasGitHubToken reports whether a GitHub API token is stored.
+func (s *FrpcService) HasGitHubToken() (bool, error) {
+	token, err := LoadGitHubToken()
+	if err != nil {
+		return false, err
+	}
+	return token != "", nil
+}
+
+// SetGitHubToken checks token against GitHub and stores it in the keyring. An empty
+// token removes the stored one.
+func (s *FrpcService) SetGitHubToken(token string) (*models.GitHubRateLimit, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, s.ClearGitHubToken()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultFrpcStatusTimeout)
+	defer cancel()
+	limit, err := s.releaseAPI.CheckToken(ctx, token)
+	if errors.Is(err, api.ErrGitHubTokenRejected) {
+		return nil, apperror.New(apperror.CodeInvalidArgument).WithDetail("GitHub 令牌无效或已过期")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := SaveGitHubToken(token); err != nil {
+		return nil, apperror.Wrap(apperror.CodeStorage, err)
+	}
+	// Responses cached for the anonymous quota stay valid, but drop them so the next
+	// lookup reports the token's quota.
+	s.etagStore.Clear()
+	return limit, nil
+}
+
+// ClearGitHubToken removes the stored GitHub API token.
+func (s *FrpcService) ClearGitHubToken() error {
+	if err := ClearGitHubToken(); err != nil {
+		return apperror.Wrap(apperror.CodeStorage, err)
+	}
+	s.etagStore.Clear()
+	return nil
+}
+
+func githubCacheSnapshotPath() string {
+	dataDir, err := userDataDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dataDir, "frpc", "github_cache.json")
 }
 
 func (s *FrpcService) buildStatus(
@@ -205,12 +286,12 @@ func (s *FrpcService) buildStatus(
 ) (*models.FrpcStatus, error) {
 	paths, err := s.paths()
 	if err != nil {
-		return nil, err
+		return nil, apperror.Wrap(apperror.CodeStorage, err)
 	}
 
 	installed, err := loadInstalledInfo(paths.StatePath, paths.BinaryPath)
 	if err != nil {
-		return nil, err
+		return nil, apperror.Wrap(apperror.CodeStorage, err)
 	}
 
 	status := &models.FrpcStatus{
@@ -226,14 +307,20 @@ func (s *FrpcService) buildStatus(
 		status.GitHubMirrorURL = ""
 	}
 
+	if hasToken, tokenErr := s.HasGitHubToken(); tokenErr == nil {
+		status.GitHubTokenSet = hasToken
+	}
+
 	resolvedLatest := latest
 	if fetchLatest && resolvedLatest == nil {
 		resolvedLatest, err = s.resolveLatestRelease(ctx)
 		if err != nil {
 			status.LatestError = err.Error()
+			status.GitHubRateLimit = s.releaseAPI.RateLimit()
 			return status, nil
 		}
 	}
+	status.GitHubRateLimit = s.releaseAPI.RateLimit()
 	if resolvedLatest != nil {
 		status.Latest = resolvedLatest
 		status.UpdateAvailable = isUpdateAvailable(installed, resolvedLatest)
@@ -288,13 +375,11 @@ func (s *FrpcService) resolveLatestRelease(ctx context.Context) (*models.FrpcRel
 }
 
 func (s *FrpcService) paths() (models.FrpcPaths, error) {
-	configDir, err := os.UserConfigDir()
+	userDataDir, err := userDataDir()
 	if err != nil {
-		return models.FrpcPaths{}, fmt.Errorf("get config dir: %w", err)
+		return models.FrpcPaths{}, err
 	}
 
-	appDir := filepath.Join(configDir, "LoliaShizuku")
-	userDataDir := filepath.Join(appDir, "userdata")
 	frpcDir := filepath.Join(userDataDir, "frpc")
 	binDir := filepath.Join(frpcDir, "bin")
 	downloadDir := filepath.Join(frpcDir, "downloads")
@@ -327,8 +412,6 @@ func (s *FrpcService) downloadArchive(ctx context.Context, url string, outputPat
 	if err != nil {
 		return "", fmt.Errorf("build download request: %w", err)
 	}
-	req.Header.Set("User-Agent", httpclient.ResolveUserAgent(""))
-
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("download release asset: %w", err)
@@ -741,7 +824,7 @@ func releaseAssetName(goos, goarch string) (string, string, error) {
 		}
 	}
 
-	return "", "", fmt.Errorf("unsupported platform: %s/%s", normalizedOS, normalizedArch)
+	return "", "", apperror.New(apperror.CodeUnsupportedPlatform, "platform", normalizedOS+"/"+normalizedArch)
 }
 
 func parseSHA256Digest(raw string) string {
@@ -763,7 +846,7 @@ func (s *FrpcService) beginInstall() (context.Context, error) {
 	defer s.installMu.Unlock()
 
 	if s.installCancel != nil {
-		return nil, fmt.Errorf("frpc 下载/安装正在进行中")
+		return nil, apperror.New(apperror.CodeInstallInProgress)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), defaultFrpcInstallTimeout)
@@ -787,12 +870,16 @@ func normalizeInstallError(err error) error {
 		return nil
 	}
 	if errors.Is(err, context.Canceled) {
-		return fmt.Errorf("frpc 下载已终止")
+		return apperror.Wrap(apperror.CodeInstallCancelled, err)
 	}
 	if errors.Is(err, context.DeadlineExceeded) {
-		return fmt.Errorf("frpc 下载超时，请稍后重试")
+		return apperror.Wrap(apperror.CodeInstallTimeout, err)
+	}
+	// Keep network, certificate and API codes; anything else is an install failure.
+	if mapped := apperror.From(err); mapped.Code != apperror.CodeUnknown {
+		return mapped
 	}
-	return err
+	return apperror.Wrap(apperror.CodeInstallFailed, err)
 }
 
 func removeIfExists(path string) error {
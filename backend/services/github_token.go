@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+const githubTokenKey = "github_token"
+
+// SaveGitHubToken stores the GitHub API token used for frpc release lookups.
+func SaveGitHubToken(token string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return fmt.Errorf("github token is empty")
+	}
+	if err := keyring.Set(tokenService, githubTokenKey, token); err != nil {
+		return fmt.Errorf("save github token to keyring: %w", err)
+	}
+	return nil
+}
+
+// LoadGitHubToken returns the stored GitHub API token, or "" when none is set.
+func LoadGitHubToken() (string, error) {
+	token, err := keyring.Get(tokenService, githubTokenKey)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("load github token from keyring: %w", err)
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// ClearGitHubToken removes the stored GitHub API token.
+func ClearGitHubToken() error {
+	err := keyring.Delete(tokenService, githubTokenKey)
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("clear github token from keyring: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"loliashizuku/backend/apperror"
+	"loliashizuku/backend/config"
+	"loliashizuku/backend/models"
+	"loliashizuku/backend/netproxy"
+	"loliashizuku/backend/tlstrust"
+)
+
+const (
+	defaultGitHubAPIBaseURL = "https://api.github.com"
+	proxyTestTimeout        = 10 * time.Second
+)
+
+var (
+	networkConfigMu     sync.RWMutex
+	networkConfigSource func() config.NetworkConfig
+
+	// outboundTransport carries all Center, GitHub, frpc download and OAuth traffic and
+	// follows the proxy and TLS settings as they change.
+	outboundTransport = &outboundRoundTripper{}
+)
+
+// outboundRoundTripper sends requests through a transport built for the current TLS
+// settings and replaces it when they change. Proxy changes need no rebuild because the
+// transport resolves the proxy per request.
+type outboundRoundTripper struct {
+	mu        sync.Mutex
+	key       string
+	transport *http.Transport
+}
+
+func (t *outboundRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.current().RoundTrip(req)
+}
+
+func (t *outboundRoundTripper) current() *http.Transport {
+	settings := currentTLSConfig()
+	host := centerAPIHost()
+	key := strings.Join(settings.CAFiles, "\x00") + "\x01" + strings.Join(settings.CenterPins, "\x00") + "\x01" + host
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.transport != nil && t.key == key {
+		return t.transport
+	}
+	if t.transport != nil {
+		t.transport.CloseIdleConnections()
+	}
+	t.transport = netproxy.NewTransport(currentProxyConfig)
+	t.transport.TLSClientConfig = outboundTLSConfig(settings, host)
+	t.key = key
+	return t.transport
+}
+
+// CloseIdleConnections lets http.Client.CloseIdleConnections reach the current transport.
+func (t *outboundRoundTripper) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.transport != nil {
+		t.transport.CloseIdleConnections()
+	}
+}
+
+// outboundTLSConfig builds the TLS settings for outbound clients. Settings that can no
+// longer be loaded, e.g. a deleted CA file, fall back to the system roots for other
+// hosts, while the pinned Center API host is refused until they load again.
+func outboundTLSConfig(settings config.TLSConfig, pinnedHost string) *tls.Config {
+	tlsConfig, _ := tlstrust.ClientConfig(settings, pinnedHost)
+	return tlsConfig
+}
+
+// currentNetworkConfig returns the saved network settings, or the environment's proxy
+// and the system roots before NetworkService is created.
+func currentNetworkConfig() config.NetworkConfig {
+	networkConfigMu.RLock()
+	source := networkConfigSource
+	networkConfigMu.RUnlock()
+	if source == nil {
+		return config.NetworkConfig{Proxy: config.ProxyConfig{Mode: config.ProxyModeSystem}}
+	}
+	return source()
+}
+
+func currentProxyConfig() config.ProxyConfig {
+	return currentNetworkConfig().Proxy
+}
+
+func currentTLSConfig() config.TLSConfig {
+	return currentNetworkConfig().TLS
+}
+
+func centerAPIHost() string {
+	u, err := url.Parse(centerAPIBaseURL())
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// frpcProxyEnv returns the extra environment passed to a started frpc.
+func frpcProxyEnv() []string {
+	return netproxy.FrpcEnv(currentProxyConfig())
+}
+
+func githubAPIBaseURL() string {
+	baseURL := strings.TrimRight(strings.TrimSpace(os.Getenv("LOLIA_GITHUB_API_BASE_URL")), "/")
+	if baseURL == "" {
+		baseURL = defaultGitHubAPIBaseURL
+	}
+	return baseURL
+}
+
+// NetworkService manages the outbound proxy and TLS trust settings.
+type NetworkService struct {
+	configManager *config.Manager
+}
+
+// NewNetworkService creates a NetworkService and makes outbound clients use the network settings saved in config.
+func NewNetworkService(configManager *config.Manager) *NetworkService {
+	networkConfigMu.Lock()
+	networkConfigSource = func() config.NetworkConfig {
+		cfg := configManager.GetConfig()
+		if cfg == nil {
+			return config.NetworkConfig{Proxy: config.ProxyConfig{Mode: config.ProxyModeSystem}}
+		}
+		return cfg.Network
+	}
+	networkConfigMu.Unlock()
+	return &NetworkService{configManager: configManager}
+}
+
+// GetProxyConfig returns the saved proxy settings.
+func (s *NetworkService) GetProxyConfig() config.ProxyConfig {
+	cfg, err := netproxy.Normalize(currentProxyConfig())
+	if err != nil {
+		return currentProxyConfig()
+	}
+	return cfg
+}
+
+// SetProxyConfig validates and saves the proxy settings. They apply to the next request.
+func (s *NetworkService) SetProxyConfig(proxy config.ProxyConfig) error {
+	normalized, err := netproxy.Normalize(proxy)
+	if err != nil {
+		return apperror.Wrap(apperror.CodeInvalidArgument, err)
+	}
+	if err := s.configManager.UpdateProxy(normalized); err != nil {
+		return apperror.Wrap(apperror.CodeStorage, err)
+	}
+	return nil
+}
+
+// GetTLSConfig returns the saved TLS trust settings.
+func (s *NetworkService) GetTLSConfig() config.TLSConfig {
+	return currentTLSConfig()
+}
+
+// SetTLSConfig validates and saves the extra CA files and Center API pins. New
+// connections are verified with them; open ones are closed once idle.
+func (s *NetworkService) SetTLSConfig(tlsConfig config.TLSConfig) error {
+	normalized, err := tlstrust.Validate(tlsConfig)
+	if err != nil {
+		return apperror.Wrap(apperror.CodeInvalidArgument, err)
+	}
+	if err := s.configManager.UpdateTLS(normalized); err != nil {
+		return apperror.Wrap(apperror.CodeStorage, err)
+	}
+	// Validate read the files once; report it if building the settings still fails.
+	if _, err := tlstrust.ClientConfig(normalized, centerAPIHost()); err != nil {
+		return apperror.Wrap(apperror.CodeCertificate, err, "host", centerAPIHost())
+	}
+	return nil
+}
+
+// TestProxyConnection checks whether the Center API and GitHub can be reached with
+// proxy, which does not need to be saved first.
+func (s *NetworkService) TestProxyConnection(proxy config.ProxyConfig) (*models.ProxyTestResult, error) {
+	normalized, err := netproxy.Normalize(proxy)
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeInvalidArgument, err)
+	}
+	transport, err := netproxy.NewStaticTransport(normalized)
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeInvalidArgument, err)
+	}
+	transport.TLSClientConfig = outboundTLSConfig(currentTLSConfig(), centerAPIHost())
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: proxyTestTimeout}
+
+	targets := []models.ProxyTestTarget{
+		{Name: "center", URL: centerAPIBaseURL()},
+		{Name: "github", URL: githubAPIBaseURL()},
+	}
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(target *models.ProxyTestTarget) {
+			defer wg.Done()
+			probeProxyTarget(client, normalized, target)
+		}(&targets[i])
+	}
+	wg.Wait()
+
+	result := &models.ProxyTestResult{Mode: normalized.Mode, Success: true, Targets: targets}
+	for _, target := range targets {
+		if !target.Reachable {
+			result.Success = false
+		}
+	}
+	return result, nil
+}
+
+// probeProxyTarget sends a GET to target. Any HTTP response counts as reachable except
+// 407, which means the proxy rejected its credentials.
+func probeProxyTarget(client *http.Client, proxy config.ProxyConfig, target *models.ProxyTestTarget) {
+	targetURL, err := url.Parse(target.URL)
+	if err != nil {
+		target.Error = err.Error()
+		return
+	}
+	if proxyURL, err := netproxy.URLFor(proxy, targetURL); err == nil && proxyURL != nil {
+		target.ProxyURL = proxyURL.Redacted()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), proxyTestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, nil)
+	if err != nil {
+		target.Error = err.Error()
+		return
+	}
+
+	startedAt := time.Now()
+	resp, err := client.Do(req)
+	target.LatencyMs = time.Since(startedAt).Milliseconds()
+	if err != nil {
+		target.Error = err.Error()
+		if chain, _, ok := tlstrust.ChainFromError(err); ok {
+			target.Certificates = chain
+		}
+		return
+	}
+	resp.Body.Close()
+
+	target.StatusCode = resp.StatusCode
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		target.Error = "代理需要认证或认证失败"
+		return
+	}
+	target.Reachable = true
+}
@@ -0,0 +1,398 @@
+package services
+
+import (
+	"context"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"loliashizuku/backend/apperror"
+	"loliashizuku/backend/models"
+)
+
+const (
+	nodeProbeCacheTTL       = 5 * time.Minute
+	nodeProbeDefaultSamples = 3
+	nodeProbeMaxSamples     = 10
+	nodeProbeDefaultTimeout = 3 * time.Second
+	nodeProbeMaxTimeout     = 10 * time.Second
+	nodeProbeSampleInterval = 200 * time.Millisecond
+	nodeProbeConcurrency    = 16
+)
+
+type nodeProbeMeasurement struct {
+	node    models.NodeItem
+	address string
+	rtts    []time.Duration
+	samples int
+	lastErr string
+}
+
+type nodeProbeSnapshot struct {
+	probedAt     time.Time
+	samples      int
+	timeout      time.Duration
+	measurements []nodeProbeMeasurement
+}
+
+// nodeProbeCall is a probe in flight that concurrent callers with the same options share.
+type nodeProbeCall struct {
+	done    chan struct{}
+	samples int
+	timeout time.Duration
+	cancel  context.CancelFunc
+	// waiters counts the callers still waiting; the probe is cancelled when it drops to 0.
+	waiters  int
+	snapshot *nodeProbeSnapshot
+	err      error
+}
+
+// NodeProbeService measures TCP connect latency to every node and recommends the best one.
+type NodeProbeService struct {
+	center *CenterService
+	dialer func(ctx context.Context, network, address string) (net.Conn, error)
+
+	mu       sync.Mutex
+	snapshot *nodeProbeSnapshot
+	probing  *nodeProbeCall
+}
+
+// NewNodeProbeService creates a new NodeProbeService backed by the given CenterService.
+func NewNodeProbeService(center *CenterService) *NodeProbeService {
+	dialer := &net.Dialer{}
+	return &NodeProbeService{
+		center: center,
+		dialer: dialer.DialContext,
+	}
+}
+
+// ProbeNodes probes all nodes (or returns a cached result) and ranks them by latency.
+func (s *NodeProbeService) ProbeNodes(requestID string, options models.NodeProbeOptions) (*models.NodeProbeReport, error) {
+	samples, timeout := normalizeNodeProbeOptions(options)
+
+	// Every step below has its own timeout, so the call as a whole is only cancellable.
+	ctx, done := s.center.requests.beginUnbounded(requestID)
+	defer done()
+
+	snapshot, cached, err := s.loadSnapshot(ctx, samples, timeout, options.Force)
+	if err != nil {
+		return nil, err
+	}
+
+	hasKYC := s.userHasKYC(ctx)
+	results := make([]models.NodeProbeResult, 0, len(snapshot.measurements))
+	for _, measurement := range snapshot.measurements {
+		result := summarizeNodeProbe(measurement)
+		result.FilterReasons = nodeProbeFilterReasons(measurement.node, options, hasKYC)
+		result.FilteredOut = len(result.FilterReasons) > 0
+		results = append(results, result)
+	}
+	rankNodeProbeResults(results)
+
+	report := &models.NodeProbeReport{
+		ProbedAt:  snapshot.probedAt.Format(time.RFC3339),
+		ExpiresAt: snapshot.probedAt.Add(nodeProbeCacheTTL).Format(time.RFC3339),
+		Cached:    cached,
+		Results:   results,
+	}
+	if len(results) > 0 && results[0].Rank == 1 {
+		best := results[0]
+		report.Recommended = &best
+	}
+	return report, nil
+}
+
+// RecommendNode returns the best eligible node, or an error when none is reachable.
+func (s *NodeProbeService) RecommendNode(requestID string, options models.NodeProbeOptions) (*models.NodeProbeResult, error) {
+	report, err := s.ProbeNodes(requestID, options)
+	if err != nil {
+		return nil, err
+	}
+	if report.Recommended == nil {
+		return nil, apperror.New(apperror.CodeNoNode)
+	}
+	return report.Recommended, nil
+}
+
+// ClearNodeProbeCache drops the cached probe results.
+func (s *NodeProbeService) ClearNodeProbeCache() {
+	s.mu.Lock()
+	s.snapshot = nil
+	s.mu.Unlock()
+}
+
+// loadSnapshot returns the cached probe results or probes every node again. Concurrent
+// callers with the same options share one probe, which runs until its last caller gives up.
+func (s *NodeProbeService) loadSnapshot(ctx context.Context, samples int, timeout time.Duration, force bool) (*nodeProbeSnapshot, bool, error) {
+	s.mu.Lock()
+	cached := s.snapshot
+	if !force && cached != nil &&
+		cached.samples == samples &&
+		cached.timeout == timeout &&
+		time.Since(cached.probedAt) < nodeProbeCacheTTL {
+		s.mu.Unlock()
+		return cached, true, nil
+	}
+
+	call := s.probing
+	if call == nil || call.samples != samples || call.timeout != timeout {
+		probeCtx, cancel := s.center.requests.beginUnbounded("")
+		call = &nodeProbeCall{done: make(chan struct{}), samples: samples, timeout: timeout, cancel: cancel}
+		s.probing = call
+		go s.runProbe(probeCtx, call)
+	}
+	call.waiters++
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		s.leaveProbe(call)
+		return call.snapshot, false, call.err
+	case <-ctx.Done():
+		s.leaveProbe(call)
+		return nil, false, ctx.Err()
+	}
+}
+
+// leaveProbe drops one waiter of call and cancels the probe when nobody waits for it.
+func (s *NodeProbeService) leaveProbe(call *nodeProbeCall) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+	if s.probing == call {
+		s.probing = nil
+	}
+	call.cancel()
+}
+
+func (s *NodeProbeService) runProbe(ctx context.Context, call *nodeProbeCall) {
+	defer close(call.done)
+	defer call.cancel()
+
+	nodesCtx, cancel := context.WithTimeout(ctx, defaultHTTPTimeout)
+	nodes, err := s.center.api.GetNodes(nodesCtx)
+	cancel()
+
+	var snapshot *nodeProbeSnapshot
+	if err == nil {
+		snapshot = &nodeProbeSnapshot{
+			probedAt:     time.Now().UTC(),
+			samples:      call.samples,
+			timeout:      call.timeout,
+			measurements: s.probeAll(ctx, nodes.Nodes, call.samples, call.timeout),
+		}
+		// A cancelled probe only has dial errors; do not report or cache it.
+		err = ctx.Err()
+	}
+
+	s.mu.Lock()
+	if s.probing == call {
+		s.probing = nil
+	}
+	if err == nil && (s.snapshot == nil || !s.snapshot.probedAt.After(snapshot.probedAt)) {
+		s.snapshot = snapshot
+	}
+	if err == nil {
+		call.snapshot = snapshot
+	}
+	call.err = err
+	s.mu.Unlock()
+}
+
+// userHasKYC reports whether the signed-in user passed real-name verification. When the
+// user info cannot be loaded, nodes that need it are treated as unavailable.
+func (s *NodeProbeService) userHasKYC(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+	defer cancel()
+	user, err := s.center.api.GetUserInfo(ctx)
+	return err == nil && user != nil && user.HasKYC
+}
+
+func (s *NodeProbeService) probeAll(ctx context.Context, nodes []models.NodeItem, samples int, timeout time.Duration) []nodeProbeMeasurement {
+	measurements := make([]nodeProbeMeasurement, len(nodes))
+	sem := make(chan struct{}, nodeProbeConcurrency)
+
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(index int, node models.NodeItem) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			measurements[index] = s.probeNode(ctx, node, samples, timeout)
+		}(i, node)
+	}
+	wg.Wait()
+	return measurements
+}
+
+func (s *NodeProbeService) probeNode(ctx context.Context, node models.NodeItem, samples int, timeout time.Duration) nodeProbeMeasurement {
+	measurement := nodeProbeMeasurement{node: node}
+
+	host := strings.TrimSpace(node.IPAddress)
+	if host == "" || node.FrpsPort <= 0 {
+		measurement.lastErr = "节点缺少地址或端口"
+		return measurement
+	}
+	measurement.address = net.JoinHostPort(host, strconv.FormatInt(node.FrpsPort, 10))
+	measurement.samples = samples
+
+	for i := 0; i < samples; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(nodeProbeSampleInterval):
+			case <-ctx.Done():
+				return measurement
+			}
+		}
+
+		dialCtx, cancel := context.WithTimeout(ctx, timeout)
+		startedAt := time.Now()
+		conn, err := s.dialer(dialCtx, "tcp", measurement.address)
+		elapsed := time.Since(startedAt)
+		cancel()
+		if err != nil {
+			measurement.lastErr = err.Error()
+			continue
+		}
+		_ = conn.Close()
+		measurement.rtts = append(measurement.rtts, elapsed)
+	}
+	return measurement
+}
+
+func normalizeNodeProbeOptions(options models.NodeProbeOptions) (int, time.Duration) {
+	samples := options.Samples
+	if samples <= 0 {
+		samples = nodeProbeDefaultSamples
+	}
+	if samples > nodeProbeMaxSamples {
+		samples = nodeProbeMaxSamples
+	}
+
+	timeout := time.Duration(options.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = nodeProbeDefaultTimeout
+	}
+	if timeout > nodeProbeMaxTimeout {
+		timeout = nodeProbeMaxTimeout
+	}
+	return samples, timeout
+}
+
+func summarizeNodeProbe(measurement nodeProbeMeasurement) models.NodeProbeResult {
+	result := models.NodeProbeResult{
+		Node:      measurement.node,
+		Address:   measurement.address,
+		Samples:   measurement.samples,
+		Successes: len(measurement.rtts),
+		LastError: measurement.lastErr,
+		LossRate:  1,
+	}
+	if measurement.samples > 0 {
+		result.LossRate = float64(measurement.samples-len(measurement.rtts)) / float64(measurement.samples)
+	}
+	if len(measurement.rtts) == 0 {
+		return result
+	}
+
+	result.Reachable = true
+	result.MinRTTMs = math.MaxFloat64
+	var total float64
+	for i, rtt := range measurement.rtts {
+		ms := durationMillis(rtt)
+		total += ms
+		result.MinRTTMs = math.Min(result.MinRTTMs, ms)
+		result.MaxRTTMs = math.Max(result.MaxRTTMs, ms)
+		if i > 0 {
+			result.JitterMs += math.Abs(ms - durationMillis(measurement.rtts[i-1]))
+		}
+	}
+	result.AvgRTTMs = total / float64(len(measurement.rtts))
+	if len(measurement.rtts) > 1 {
+		result.JitterMs /= float64(len(measurement.rtts) - 1)
+	}
+	return result
+}
+
+func nodeProbeFilterReasons(node models.NodeItem, options models.NodeProbeOptions, userHasKYC bool) []string {
+	var reasons []string
+	if !options.IncludeOffline && !isNodeOnline(node.Status) {
+		reasons = append(reasons, "节点不在线")
+	}
+
+	protocol := strings.ToLower(strings.TrimSpace(options.Protocol))
+	if protocol != "" {
+		supported := false
+		for _, item := range node.SupportedProtocols {
+			if strings.EqualFold(strings.TrimSpace(item), protocol) {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			reasons = append(reasons, "节点不支持协议 "+protocol)
+		}
+	}
+
+	if node.NeedKYC && !userHasKYC {
+		reasons = append(reasons, "节点需要实名认证")
+	}
+	return reasons
+}
+
+// isNodeOnline reports whether a Center API node status means the node accepts
+// connections. Only exact values count, so e.g. "not_running" is offline.
+func isNodeOnline(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "online", "running", "active":
+		return true
+	}
+	return false
+}
+
+// rankNodeProbeResults sorts eligible reachable nodes by loss, then latency plus jitter,
+// and assigns 1-based ranks. Filtered-out and unreachable nodes keep rank 0.
+func rankNodeProbeResults(results []models.NodeProbeResult) {
+	eligible := func(result models.NodeProbeResult) bool {
+		return result.Reachable && !result.FilteredOut
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if eligible(a) != eligible(b) {
+			return eligible(a)
+		}
+		if a.Reachable != b.Reachable {
+			return a.Reachable
+		}
+		if a.LossRate != b.LossRate {
+			return a.LossRate < b.LossRate
+		}
+		return a.AvgRTTMs+a.JitterMs < b.AvgRTTMs+b.JitterMs
+	})
+
+	rank := 0
+	for i := range results {
+		if !eligible(results[i]) {
+			continue
+		}
+		rank++
+		results[i].Rank = rank
+	}
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,481 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"loliashizuku/backend/api"
+	"loliashizuku/backend/config"
+	"loliashizuku/backend/models"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	nodeWatchInterval      = time.Minute
+	nodeWatchInitialDelay  = 15 * time.Second
+	nodeStatusHistoryFile  = "node_status_history.json"
+	nodeStatusHistoryDays  = 30
+	nodeUptimeDefaultDays  = 7
+	nodeStatusChangedEvent = "node_status_changed"
+	nodeRunnerImpactEvent  = "node_runner_impact"
+)
+
+// NodeWatcherService polls node status in the background, records status transitions
+// and reacts when the node backing the running tunnel goes offline or comes back.
+type NodeWatcherService struct {
+	center        *CenterService
+	configManager *config.Manager
+
+	mu            sync.Mutex
+	ctx           context.Context
+	loopOnce      sync.Once
+	loaded        bool
+	history       []models.NodeStatusTransition
+	latest        map[int64]models.NodeStatusTransition
+	paused        *models.PausedRunner
+	lastCheckedAt time.Time
+	lastError     string
+}
+
+// NewNodeWatcherService creates a new NodeWatcherService.
+func NewNodeWatcherService(center *CenterService, configManager *config.Manager) *NodeWatcherService {
+	return &NodeWatcherService{
+		center:        center,
+		configManager: configManager,
+		latest:        map[int64]models.NodeStatusTransition{},
+	}
+}
+
+// Start launches the polling loop. It stops when ctx is done.
+func (s *NodeWatcherService) Start(ctx context.Context) {
+	s.loopOnce.Do(func() {
+		s.mu.Lock()
+		s.ctx = ctx
+		s.mu.Unlock()
+		go s.loopWatch(ctx)
+	})
+}
+
+// GetNodeWatcherStatus reports when nodes were last checked and whether a runner is paused.
+func (s *NodeWatcherService) GetNodeWatcherStatus() (*models.NodeWatcherStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statusLocked(), nil
+}
+
+// CheckNodeStatusNow polls node status immediately.
+func (s *NodeWatcherService) CheckNodeStatusNow(requestID string) (*models.NodeWatcherStatus, error) {
+	ctx, done := s.center.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	if err := s.poll(ctx); err != nil {
+		return nil, err
+	}
+	return s.GetNodeWatcherStatus()
+}
+
+// GetNodeStatusHistory returns recorded transitions of a node (or of all nodes when
+// nodeID is 0) over the last days, newest first.
+func (s *NodeWatcherService) GetNodeStatusHistory(nodeID int64, days int) ([]models.NodeStatusTransition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -normalizeNodeUptimeDays(days))
+	result := []models.NodeStatusTransition{}
+	for i := len(s.history) - 1; i >= 0; i-- {
+		transition := s.history[i]
+		if nodeID > 0 && transition.NodeID != nodeID {
+			continue
+		}
+		if at, err := time.Parse(time.RFC3339, transition.At); err == nil && at.Before(since) {
+			continue
+		}
+		result = append(result, transition)
+	}
+	return result, nil
+}
+
+// GetNodeUptime summarizes the observed availability of every known node over the
+// last days. Between observations a node keeps its last recorded status.
+func (s *NodeWatcherService) GetNodeUptime(days int) ([]models.NodeUptime, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	since := now.AddDate(0, 0, -normalizeNodeUptimeDays(days))
+	byNode := map[int64][]models.NodeStatusTransition{}
+	for _, transition := range s.history {
+		byNode[transition.NodeID] = append(byNode[transition.NodeID], transition)
+	}
+
+	result := make([]models.NodeUptime, 0, len(s.latest))
+	for nodeID, latest := range s.latest {
+		result = append(result, summarizeNodeUptime(latest, byNode[nodeID], since, now))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].NodeID < result[j].NodeID
+	})
+	return result, nil
+}
+
+func (s *NodeWatcherService) loopWatch(ctx context.Context) {
+	timer := time.NewTimer(nodeWatchInitialDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if has, err := HasOAuthToken(); err == nil && has {
+			pollCtx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+			_ = s.poll(pollCtx)
+			cancel()
+		}
+		timer.Reset(nodeWatchInterval)
+	}
+}
+
+func (s *NodeWatcherService) poll(ctx context.Context) error {
+	nodes, err := s.center.api.GetNodes(api.BypassCache(ctx))
+
+	s.mu.Lock()
+	s.lastCheckedAt = time.Now().UTC()
+	if err != nil {
+		s.lastError = err.Error()
+		s.mu.Unlock()
+		return err
+	}
+	s.lastError = ""
+	changed, err := s.recordLocked(nodes.Nodes)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, transition := range changed {
+		s.emit(nodeStatusChangedEvent, transition)
+		s.handleRunnerImpact(transition)
+	}
+	return nil
+}
+
+// recordLocked stores a transition for every node whose status differs from the last
+// recorded one and returns the transitions of nodes that were already known.
+func (s *NodeWatcherService) recordLocked(nodes []models.NodeItem) ([]models.NodeStatusTransition, error) {
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var recorded, changed []models.NodeStatusTransition
+	for _, node := range nodes {
+		status := strings.ToLower(strings.TrimSpace(node.Status))
+		previous, known := s.latest[node.ID]
+		if known && previous.To == status {
+			continue
+		}
+
+		transition := models.NodeStatusTransition{
+			NodeID:   node.ID,
+			NodeName: node.Name,
+			From:     previous.To,
+			To:       status,
+			Online:   isNodeOnline(status),
+			LastSeen: node.LastSeen,
+			At:       now,
+		}
+		s.latest[node.ID] = transition
+		recorded = append(recorded, transition)
+		if known {
+			changed = append(changed, transition)
+		}
+	}
+	if len(recorded) == 0 {
+		return nil, nil
+	}
+
+	s.history = pruneNodeStatusHistory(append(s.history, recorded...), s.latest)
+	if err := saveNodeStatusHistory(s.history); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+func (s *NodeWatcherService) handleRunnerImpact(transition models.NodeStatusTransition) {
+	previousOnline := isNodeOnline(transition.From)
+	if previousOnline == transition.Online {
+		return
+	}
+
+	runner, _ := s.center.GetRunnerRuntimeStatus()
+	if transition.Online {
+		s.resumeRunner(transition, runner)
+		return
+	}
+	if runner == nil || !runner.Running || runner.NodeID != transition.NodeID {
+		return
+	}
+
+	impact := models.NodeRunnerImpact{
+		Transition: transition,
+		TunnelName: runner.TunnelName,
+		AccountID:  runner.AccountID,
+		Action:     models.NodeRunnerActionNone,
+	}
+	if s.pauseEnabled() {
+		s.center.noteRunnerEvent(fmt.Sprintf("[runner] paused: node %s is %s", transition.NodeName, transition.To))
+		if _, err := s.center.StopRunner(); err != nil {
+			impact.Error = err.Error()
+		} else {
+			impact.Action = models.NodeRunnerActionPaused
+			s.mu.Lock()
+			s.paused = &models.PausedRunner{
+				TunnelName: runner.TunnelName,
+				NodeID:     transition.NodeID,
+				AccountID:  runner.AccountID,
+				PausedAt:   transition.At,
+			}
+			s.mu.Unlock()
+		}
+	}
+	s.emit(nodeRunnerImpactEvent, impact)
+}
+
+func (s *NodeWatcherService) resumeRunner(transition models.NodeStatusTransition, runner *models.RunnerRuntimeStatus) {
+	s.mu.Lock()
+	paused := s.paused
+	if paused != nil && paused.NodeID == transition.NodeID {
+		s.paused = nil
+	}
+	s.mu.Unlock()
+
+	if paused == nil || paused.NodeID != transition.NodeID {
+		if runner != nil && runner.Running && runner.NodeID == transition.NodeID {
+			s.emit(nodeRunnerImpactEvent, models.NodeRunnerImpact{
+				Transition: transition,
+				TunnelName: runner.TunnelName,
+				AccountID:  runner.AccountID,
+				Action:     models.NodeRunnerActionNone,
+			})
+		}
+		return
+	}
+
+	impact := models.NodeRunnerImpact{
+		Transition: transition,
+		TunnelName: paused.TunnelName,
+		AccountID:  paused.AccountID,
+		Action:     models.NodeRunnerActionNone,
+	}
+	var activeID int64
+	if account := activeAccount(); account != nil {
+		activeID = account.ID
+	}
+	switch {
+	case runner != nil && runner.Running:
+		impact.Error = "已有其他隧道在运行，未自动恢复"
+	case activeID != paused.AccountID:
+		impact.Error = "当前账号已切换，未自动恢复"
+	default:
+		if _, err := s.center.StartRunner("", paused.TunnelName); err != nil {
+			impact.Error = err.Error()
+		} else {
+			impact.Action = models.NodeRunnerActionResumed
+			s.center.noteRunnerEvent(fmt.Sprintf("[runner] resumed: node %s is %s", transition.NodeName, transition.To))
+		}
+	}
+	s.emit(nodeRunnerImpactEvent, impact)
+}
+
+func (s *NodeWatcherService) pauseEnabled() bool {
+	return s.configManager != nil && s.configManager.GetConfig().App.PauseOnNodeDown
+}
+
+func (s *NodeWatcherService) emit(event string, payload interface{}) {
+	s.mu.Lock()
+	ctx := s.ctx
+	s.mu.Unlock()
+	if ctx == nil {
+		return
+	}
+	runtime.EventsEmit(ctx, event, payload)
+}
+
+func (s *NodeWatcherService) statusLocked() *models.NodeWatcherStatus {
+	status := &models.NodeWatcherStatus{
+		LastError: s.lastError,
+		AutoPause: s.pauseEnabled(),
+	}
+	if !s.lastCheckedAt.IsZero() {
+		status.LastCheckedAt = s.lastCheckedAt.Format(time.RFC3339)
+	}
+	if s.paused != nil {
+		paused := *s.paused
+		status.PausedRunner = &paused
+	}
+	return status
+}
+
+func (s *NodeWatcherService) loadLocked() error {
+	if s.loaded {
+		return nil
+	}
+	history, err := loadNodeStatusHistory()
+	if err != nil {
+		return err
+	}
+	s.history = history
+	for _, transition := range history {
+		s.latest[transition.NodeID] = transition
+	}
+	s.loaded = true
+	return nil
+}
+
+func summarizeNodeUptime(latest models.NodeStatusTransition, transitions []models.NodeStatusTransition, since, now time.Time) models.NodeUptime {
+	uptime := models.NodeUptime{
+		NodeID:      latest.NodeID,
+		NodeName:    latest.NodeName,
+		Status:      latest.To,
+		Online:      latest.Online,
+		LastSeen:    latest.LastSeen,
+		Transitions: []models.NodeStatusTransition{},
+	}
+
+	var (
+		observed, online time.Duration
+		cursor           time.Time
+		current          bool
+		started          bool
+	)
+	for _, transition := range transitions {
+		at, err := time.Parse(time.RFC3339, transition.At)
+		if err != nil {
+			continue
+		}
+		if at.Before(since) {
+			cursor, current, started = since, transition.Online, true
+			continue
+		}
+		if started {
+			observed += at.Sub(cursor)
+			if current {
+				online += at.Sub(cursor)
+			}
+		} else {
+			uptime.Since = transition.At
+		}
+		if started && current && !transition.Online {
+			uptime.OutageCount++
+		}
+		cursor, current, started = at, transition.Online, true
+		uptime.Transitions = append(uptime.Transitions, transition)
+	}
+	if !started {
+		return uptime
+	}
+	if uptime.Since == "" {
+		uptime.Since = since.Format(time.RFC3339)
+	}
+	observed += now.Sub(cursor)
+	if current {
+		online += now.Sub(cursor)
+	}
+
+	if observed > 0 {
+		uptime.UptimePercent = float64(online) / float64(observed) * 100
+	} else if current {
+		uptime.UptimePercent = 100
+	}
+	return uptime
+}
+
+// pruneNodeStatusHistory drops transitions older than the retention window but keeps
+// the latest transition of every node so its current status survives.
+func pruneNodeStatusHistory(history []models.NodeStatusTransition, latest map[int64]models.NodeStatusTransition) []models.NodeStatusTransition {
+	cutoff := time.Now().UTC().AddDate(0, 0, -nodeStatusHistoryDays)
+	pruned := make([]models.NodeStatusTransition, 0, len(history))
+	for _, transition := range history {
+		at, err := time.Parse(time.RFC3339, transition.At)
+		if err == nil && at.Before(cutoff) && latest[transition.NodeID] != transition {
+			continue
+		}
+		pruned = append(pruned, transition)
+	}
+	return pruned
+}
+
+func normalizeNodeUptimeDays(days int) int {
+	if days <= 0 {
+		return nodeUptimeDefaultDays
+	}
+	if days > nodeStatusHistoryDays {
+		return nodeStatusHistoryDays
+	}
+	return days
+}
+
+func nodeStatusHistoryPath() (string, error) {
+	dataDir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, nodeStatusHistoryFile), nil
+}
+
+func loadNodeStatusHistory() ([]models.NodeStatusTransition, error) {
+	path, err := nodeStatusHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []models.NodeStatusTransition{}, nil
+		}
+		return nil, fmt.Errorf("read node status history: %w", err)
+	}
+
+	var history []models.NodeStatusTransition
+	if err := json.Unmarshal(raw, &history); err != nil {
+		return nil, fmt.Errorf("decode node status history: %w", err)
+	}
+	return history, nil
+}
+
+func saveNodeStatusHistory(history []models.NodeStatusTransition) error {
+	path, err := nodeStatusHistoryPath()
+	if err != nil {
+		return err
+	}
+	if err := ensureDirs(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	payload, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode node status history: %w", err)
+	}
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		return fmt.Errorf("write node status history: %w", err)
+	}
+	return nil
+}
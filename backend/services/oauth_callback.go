@@ -0,0 +1,205 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"loliashizuku/backend/apperror"
+	"loliashizuku/backend/models"
+	"loliashizuku/backend/portowner"
+)
+
+const (
+	// oauthCallbackPortsEnv lists the callback ports to try in order, e.g.
+	// "1145,1146-1150,0"; 0 asks the OS for an ephemeral port.
+	oauthCallbackPortsEnv = "LOLIA_OAUTH_CALLBACK_PORTS"
+	// ephemeralPortAttempts bounds retries when an ephemeral IPv4 port is taken on ::1.
+	ephemeralPortAttempts = 5
+)
+
+// oauthCallbackListener holds the loopback listeners of the login redirect and the
+// redirect URI for the port that was actually bound.
+type oauthCallbackListener struct {
+	listeners   []net.Listener
+	redirectURL string
+	// fallback is set when earlier candidate ports were in use.
+	fallback *models.OAuthPortFallback
+}
+
+func (l *oauthCallbackListener) Close() {
+	for _, listener := range l.listeners {
+		_ = listener.Close()
+	}
+}
+
+// listenOAuthCallback binds the first free candidate port on the loopback addresses of
+// redirectURI. RFC 8252 section 7.3 lets loopback redirects use any port, so the
+// returned redirect URI carries the bound port instead of the configured one.
+func listenOAuthCallback(redirectURI *url.URL) (*oauthCallbackListener, error) {
+	hostname := redirectURI.Hostname()
+	hosts, err := loopbackListenHosts(hostname)
+	if err != nil {
+		return nil, err
+	}
+	ports, err := oauthCallbackPorts(redirectURI.Port())
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeInvalidArgument, err)
+	}
+
+	var busy []int
+	for _, port := range ports {
+		listeners, bound, err := listenLoopback(hosts, port)
+		if portowner.IsAddrInUse(err) {
+			busy = append(busy, port)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listen oauth callback on port %d: %w", port, err)
+		}
+
+		redirect := *redirectURI
+		redirect.Host = net.JoinHostPort(hostname, strconv.Itoa(bound))
+		callback := &oauthCallbackListener{listeners: listeners, redirectURL: redirect.String()}
+		if len(busy) > 0 {
+			callback.fallback = &models.OAuthPortFallback{
+				BusyPorts: busy,
+				Owners:    describePortOwners(busy),
+				Port:      bound,
+			}
+		}
+		return callback, nil
+	}
+	return nil, portInUseError(busy)
+}
+
+// loopbackListenHosts returns the addresses to listen on for a redirect host. Only
+// loopback hosts are accepted. localhost may resolve to either family in the browser,
+// so it is served on 127.0.0.1 and, when available, ::1.
+func loopbackListenHosts(hostname string) ([]string, error) {
+	if strings.EqualFold(hostname, "localhost") {
+		return []string{"127.0.0.1", "::1"}, nil
+	}
+	ip := net.ParseIP(hostname)
+	if ip == nil || !ip.IsLoopback() {
+		return nil, fmt.Errorf("redirect_uri host %q must be localhost or a loopback IP", hostname)
+	}
+	return []string{ip.String()}, nil
+}
+
+// listenLoopback listens on port on every host and returns the bound port. The first
+// host is required; the others are skipped when their address family is unavailable.
+func listenLoopback(hosts []string, port int) ([]net.Listener, int, error) {
+	attempts := 1
+	if port == 0 {
+		attempts = ephemeralPortAttempts
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		var listeners []net.Listener
+		listeners, port, err = listenLoopbackOnce(hosts, port)
+		if err == nil {
+			return listeners, port, nil
+		}
+		if attempts > 1 && portowner.IsAddrInUse(err) {
+			port = 0
+			continue
+		}
+		break
+	}
+	return nil, 0, err
+}
+
+func listenLoopbackOnce(hosts []string, port int) ([]net.Listener, int, error) {
+	primary, err := net.Listen("tcp", net.JoinHostPort(hosts[0], strconv.Itoa(port)))
+	if err != nil {
+		return nil, port, err
+	}
+	bound := primary.Addr().(*net.TCPAddr).Port
+	listeners := []net.Listener{primary}
+
+	for _, host := range hosts[1:] {
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(bound)))
+		if err == nil {
+			listeners = append(listeners, listener)
+			continue
+		}
+		if portowner.IsAddrInUse(err) {
+			for _, opened := range listeners {
+				_ = opened.Close()
+			}
+			return nil, port, err
+		}
+	}
+	return listeners, bound, nil
+}
+
+// oauthCallbackPorts returns the candidate ports from LOLIA_OAUTH_CALLBACK_PORTS, or the
+// redirect URI's port followed by an ephemeral one.
+func oauthCallbackPorts(redirectPort string) ([]int, error) {
+	spec := strings.TrimSpace(os.Getenv(oauthCallbackPortsEnv))
+	if spec == "" {
+		spec = "0"
+		if redirectPort != "" {
+			spec = redirectPort + ",0"
+		}
+	}
+
+	var ports []int
+	seen := map[int]struct{}{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		low, high, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(low))
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(strings.TrimSpace(high))
+		}
+		if err != nil || first < 0 || last > 65535 || first > last || (isRange && first == 0) {
+			return nil, fmt.Errorf("invalid %s entry %q", oauthCallbackPortsEnv, part)
+		}
+		for port := first; port <= last; port++ {
+			if _, ok := seen[port]; !ok {
+				seen[port] = struct{}{}
+				ports = append(ports, port)
+			}
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("%s lists no ports", oauthCallbackPortsEnv)
+	}
+	return ports, nil
+}
+
+// portInUseError reports that every candidate port is taken, naming the processes
+// that hold them when they can be found.
+func portInUseError(busy []int) error {
+	names := make([]string, 0, len(busy))
+	for _, port := range busy {
+		names = append(names, strconv.Itoa(port))
+	}
+	return apperror.New(apperror.CodePortInUse, "port", strings.Join(names, ", ")).
+		WithDetail(describePortOwners(busy))
+}
+
+func describePortOwners(ports []int) string {
+	var parts []string
+	for _, port := range ports {
+		owners, err := portowner.Lookup(port)
+		if err != nil || len(owners) == 0 {
+			continue
+		}
+		if len(ports) == 1 {
+			return portowner.Describe(owners)
+		}
+		parts = append(parts, fmt.Sprintf("%d: %s", port, portowner.Describe(owners)))
+	}
+	return strings.Join(parts, "; ")
+}
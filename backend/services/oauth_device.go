@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	defaultOAuthDeviceAuthURL = "https://api.lolia.link/api/v1/oauth2/device_authorization"
+
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	// defaultDevicePollInterval and deviceSlowDownStep come from RFC 8628 section 3.5.
+	defaultDevicePollInterval = 5 * time.Second
+	deviceSlowDownStep        = 5 * time.Second
+	// defaultDeviceCodeLifetime bounds polling when the server omits expires_in.
+	defaultDeviceCodeLifetime = 15 * time.Minute
+)
+
+// deviceTokenError is an RFC 6749 error response from the token endpoint.
+type deviceTokenError struct {
+	Code        string
+	Description string
+}
+
+func (e *deviceTokenError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("oauth device token: %s: %s", e.Code, e.Description)
+	}
+	return "oauth device token: " + e.Code
+}
+
+// requestDeviceAuthorization asks the authorization server for a device code and the
+// user code to show.
+func requestDeviceAuthorization(ctx context.Context, cfg *oauth2.Config) (*oauth2.DeviceAuthResponse, error) {
+	options := []oauth2.AuthCodeOption{}
+	if cfg.ClientSecret != "" {
+		options = append(options, oauth2.SetAuthURLParam("client_secret", cfg.ClientSecret))
+	}
+	authorization, err := cfg.DeviceAuth(withOAuthHTTPClient(ctx), options...)
+	if err != nil {
+		return nil, fmt.Errorf("request device authorization: %w", err)
+	}
+	if authorization.DeviceCode == "" || authorization.UserCode == "" || authorization.VerificationURI == "" {
+		return nil, fmt.Errorf("device authorization response is incomplete")
+	}
+	if authorization.Expiry.IsZero() {
+		authorization.Expiry = time.Now().Add(defaultDeviceCodeLifetime)
+	}
+	return authorization, nil
+}
+
+// pollDeviceToken polls the token endpoint until the user approves or denies the
+// request or the device code expires. authorization_pending keeps the interval and
+// slow_down increases it by 5 seconds for all later requests; onInterval reports each
+// change. Network failures are retried at the current interval.
+func pollDeviceToken(ctx context.Context, cfg *oauth2.Config, authorization *oauth2.DeviceAuthResponse, onInterval func(time.Duration)) (*oauth2.Token, error) {
+	ctx, cancel := context.WithDeadline(ctx, authorization.Expiry)
+	defer cancel()
+
+	interval := time.Duration(authorization.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDevicePollInterval
+	}
+
+	form := url.Values{
+		"grant_type":  {deviceCodeGrantType},
+		"device_code": {authorization.DeviceCode},
+		"client_id":   {cfg.ClientID},
+	}
+	if cfg.ClientSecret != "" {
+		form.Set("client_secret", cfg.ClientSecret)
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		token, err := requestDeviceToken(ctx, cfg.Endpoint.TokenURL, form)
+		if err == nil {
+			return token, nil
+		}
+
+		var tokenErr *deviceTokenError
+		var urlErr *url.Error
+		switch {
+		case errors.As(err, &tokenErr) && tokenErr.Code == "authorization_pending":
+		case errors.As(err, &tokenErr) && tokenErr.Code == "slow_down":
+			interval += deviceSlowDownStep
+			if onInterval != nil {
+				onInterval(interval)
+			}
+		case errors.As(err, &urlErr) && ctx.Err() == nil:
+		default:
+			return nil, err
+		}
+		timer.Reset(interval)
+	}
+}
+
+func requestDeviceToken(ctx context.Context, tokenURL string, form url.Values) (*oauth2.Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("build device token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := oauthHTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read device token response: %w", err)
+	}
+
+	var payload struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil && resp.StatusCode < 300 {
+		return nil, fmt.Errorf("decode device token response: %w", err)
+	}
+
+	if payload.Error != "" {
+		return nil, &deviceTokenError{Code: payload.Error, Description: payload.ErrorDescription}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("device token request failed: %s", resp.Status)
+	}
+	if payload.AccessToken == "" {
+		return nil, fmt.Errorf("device token response has no access_token")
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  payload.AccessToken,
+		TokenType:    payload.TokenType,
+		RefreshToken: payload.RefreshToken,
+	}
+	if payload.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(payload.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -13,6 +13,8 @@ import (
 	"strings"
 	"time"
 
+	"loliashizuku/backend/models"
+
 	"github.com/pkg/browser"
 	"golang.org/x/oauth2"
 )
@@ -60,6 +62,11 @@ func resolveOAuthConfig() (*oauth2.Config, error) {
 		tokenURL = defaultOAuthTokenURL
 	}
 
+	deviceAuthURL := strings.TrimSpace(os.Getenv("LOLIA_OAUTH_DEVICE_AUTH_URL"))
+	if deviceAuthURL == "" {
+		deviceAuthURL = defaultOAuthDeviceAuthURL
+	}
+
 	redirectURL := strings.TrimSpace(os.Getenv("LOLIA_OAUTH_REDIRECT_URL"))
 	if redirectURL == "" {
 		redirectURL = defaultOAuthRedirectURL
@@ -76,9 +83,10 @@ func resolveOAuthConfig() (*oauth2.Config, error) {
 		RedirectURL:  redirectURL,
 		Scopes:       strings.Fields(defaultOAuthScope),
 		Endpoint: oauth2.Endpoint{
-			AuthURL:   authorizeURL,
-			TokenURL:  tokenURL,
-			AuthStyle: oauth2.AuthStyleInParams,
+			AuthURL:       authorizeURL,
+			TokenURL:      tokenURL,
+			DeviceAuthURL: deviceAuthURL,
+			AuthStyle:     oauth2.AuthStyleInParams,
 		},
 	}, nil
 }
@@ -91,7 +99,9 @@ func randomURLSafeString(size int) (string, error) {
 	return base64.RawURLEncoding.EncodeToString(buf), nil
 }
 
-func beginOAuthLogin() error {
+// beginOAuthLogin runs the authorization code login. onFallback, when set, is called
+// as soon as the callback listens on another port than the configured one.
+func beginOAuthLogin(onFallback func(models.OAuthPortFallback)) error {
 	oauthCfg, err := resolveOAuthConfig()
 	if err != nil {
 		return err
@@ -173,23 +183,30 @@ func beginOAuthLogin() error {
 		}
 	})
 
-	listener, err := net.Listen("tcp", redirectURI.Host)
+	callback, err := listenOAuthCallback(redirectURI)
 	if err != nil {
-		return fmt.Errorf("listen oauth callback %s: %w", redirectURI.Host, err)
+		return err
+	}
+	defer callback.Close()
+	oauthCfg.RedirectURL = callback.redirectURL
+	if callback.fallback != nil && onFallback != nil {
+		onFallback(*callback.fallback)
 	}
 
 	server := &http.Server{
 		Handler: mux,
 	}
 
-	go func() {
-		if serveErr := server.Serve(listener); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
-			select {
-			case resultCh <- oauthCallbackResult{err: fmt.Errorf("oauth callback server error: %w", serveErr)}:
-			default:
+	for _, listener := range callback.listeners {
+		go func(listener net.Listener) {
+			if serveErr := server.Serve(listener); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+				select {
+				case resultCh <- oauthCallbackResult{err: fmt.Errorf("oauth callback server error: %w", serveErr)}:
+				default:
+				}
 			}
-		}
-	}()
+		}(listener)
+	}
 
 	defer func() {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -221,6 +238,7 @@ func beginOAuthLogin() error {
 
 	tokenCtx, cancel := context.WithTimeout(context.Background(), oauthTokenTimeout)
 	defer cancel()
+	tokenCtx = withOAuthHTTPClient(tokenCtx)
 
 	exchangeOptions := []oauth2.AuthCodeOption{}
 	if usePKCE {
@@ -232,8 +250,5 @@ func beginOAuthLogin() error {
 		return fmt.Errorf("exchange oauth code for token: %w", err)
 	}
 
-	if err := SaveOAuthToken(token); err != nil {
-		return err
-	}
-	return nil
+	return completeOAuthLogin(tokenCtx, token)
 }
@@ -8,8 +8,11 @@ import (
 	"golang.org/x/oauth2"
 )
 
-func loadOrRefreshOAuthToken(ctx context.Context) (*oauth2.Token, error) {
-	token, err := LoadOAuthToken()
+// loadOrRefreshOAuthToken loads the token stored under key and refreshes it when it
+// has expired. The refreshed token is saved under the same key even if another
+// account became active in the meantime.
+func loadOrRefreshOAuthToken(ctx context.Context, key string) (*oauth2.Token, error) {
+	token, err := readOAuthToken(key)
 	if err != nil {
 		return nil, fmt.Errorf("load oauth token: %w", err)
 	}
@@ -29,12 +32,12 @@ func loadOrRefreshOAuthToken(ctx context.Context) (*oauth2.Token, error) {
 		return nil, fmt.Errorf("load oauth config for refresh: %w", cfgErr)
 	}
 
-	refreshedToken, refreshErr := oauthCfg.TokenSource(ctx, token).Token()
+	refreshedToken, refreshErr := oauthCfg.TokenSource(withOAuthHTTPClient(ctx), token).Token()
 	if refreshErr != nil {
 		return nil, fmt.Errorf("refresh oauth token: %w", refreshErr)
 	}
 
-	if saveErr := SaveOAuthToken(refreshedToken); saveErr != nil {
+	if saveErr := writeOAuthToken(key, refreshedToken); saveErr != nil {
 		return nil, fmt.Errorf("save refreshed oauth token: %w", saveErr)
 	}
 
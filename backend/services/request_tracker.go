@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultRequestTimeout = 30 * time.Second
+
+// requestTracker derives per-call contexts from an app-lifetime root context and keeps
+// the cancel functions of in-flight calls so the frontend can abort them by request ID.
+type requestTracker struct {
+	mu         sync.Mutex
+	root       context.Context
+	rootCancel context.CancelFunc
+	inflight   map[string]*trackedRequest
+}
+
+type trackedRequest struct {
+	cancel context.CancelFunc
+}
+
+func newRequestTracker() *requestTracker {
+	root, cancel := context.WithCancel(context.Background())
+	return &requestTracker{
+		root:       root,
+		rootCancel: cancel,
+		inflight:   map[string]*trackedRequest{},
+	}
+}
+
+// start rebinds the root context to the app context; calls already in flight keep the old root.
+func (t *requestTracker) start(ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+	root, cancel := context.WithCancel(ctx)
+
+	t.mu.Lock()
+	t.root = root
+	t.rootCancel = cancel
+	t.mu.Unlock()
+}
+
+// shutdown cancels the root context and therefore every in-flight call.
+func (t *requestTracker) shutdown() {
+	t.mu.Lock()
+	cancel := t.rootCancel
+	t.inflight = map[string]*trackedRequest{}
+	t.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// begin returns a context for one call with the given deadline. A non-empty requestID
+// registers the call for cancelRequest; reusing an ID cancels the previous call.
+// The returned func must be called when the call finishes.
+func (t *requestTracker) begin(requestID string, timeout time.Duration) (context.Context, func()) {
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	return t.track(requestID, func(root context.Context) (context.Context, context.CancelFunc) {
+		return context.WithTimeout(root, timeout)
+	})
+}
+
+// beginUnbounded is begin without a deadline, for calls that bound each of their
+// steps with their own timeout.
+func (t *requestTracker) beginUnbounded(requestID string) (context.Context, func()) {
+	return t.track(requestID, func(root context.Context) (context.Context, context.CancelFunc) {
+		return context.WithCancel(root)
+	})
+}
+
+func (t *requestTracker) track(
+	requestID string,
+	derive func(root context.Context) (context.Context, context.CancelFunc),
+) (context.Context, func()) {
+	t.mu.Lock()
+	ctx, cancel := derive(t.root)
+
+	id := strings.TrimSpace(requestID)
+	if id == "" {
+		t.mu.Unlock()
+		return ctx, cancel
+	}
+
+	if previous, ok := t.inflight[id]; ok {
+		previous.cancel()
+	}
+	tracked := &trackedRequest{cancel: cancel}
+	t.inflight[id] = tracked
+	t.mu.Unlock()
+
+	return ctx, func() {
+		cancel()
+		t.mu.Lock()
+		// Only remove our own entry; the ID may have been reused by a newer call.
+		if t.inflight[id] == tracked {
+			delete(t.inflight, id)
+		}
+		t.mu.Unlock()
+	}
+}
+
+// cancelRequest aborts the in-flight call registered under requestID.
+func (t *requestTracker) cancelRequest(requestID string) bool {
+	id := strings.TrimSpace(requestID)
+
+	t.mu.Lock()
+	tracked, ok := t.inflight[id]
+	delete(t.inflight, id)
+	t.mu.Unlock()
+
+	if ok {
+		tracked.cancel()
+	}
+	return ok
+}
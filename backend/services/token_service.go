@@ -3,22 +3,45 @@ package services
 import (
 	"context"
 	"errors"
+	"sync"
+	"time"
 
+	"loliashizuku/backend/models"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
+)
+
+const (
+	deviceLoginEvent       = "oauth_device_login"
+	oauthPortFallbackEvent = "oauth_port_fallback"
 )
 
 // TokenService exposes token helpers to the frontend via Wails binding.
-type TokenService struct{}
+type TokenService struct {
+	mu           sync.Mutex
+	ctx          context.Context
+	device       *models.DeviceLoginStatus
+	cancelDevice context.CancelFunc
+}
 
 // NewTokenService creates a new TokenService instance.
 func NewTokenService() *TokenService {
 	return &TokenService{}
 }
 
+// Startup keeps the Wails context used to emit device login events.
+func (s *TokenService) Startup(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+}
+
 // HasOAuthToken checks whether an OAuth token exists in the system keyring.
 func (s *TokenService) HasOAuthToken() (bool, error) {
 	ctx := context.Background()
-	token, err := loadOrRefreshOAuthToken(ctx)
+	token, err := loadOrRefreshOAuthToken(ctx, activeTokenKey())
 	if err == nil {
 		return token != nil, nil
 	}
@@ -30,13 +53,156 @@ func (s *TokenService) HasOAuthToken() (bool, error) {
 
 // BeginOAuthLogin starts OAuth2 Authorization Code login and stores token in keyring.
 func (s *TokenService) BeginOAuthLogin() (bool, error) {
-	if err := beginOAuthLogin(); err != nil {
+	if err := beginOAuthLogin(s.emitPortFallback); err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
+// BeginDeviceLogin starts an RFC 8628 device authorization login for machines without
+// a local browser, e.g. over SSH. It returns the user code and verification URL to
+// show and keeps polling in the background; every state change is emitted as
+// "oauth_device_login". A login already in progress is cancelled.
+func (s *TokenService) BeginDeviceLogin() (*models.DeviceLoginStatus, error) {
+	oauthCfg, err := resolveOAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	requestCtx, cancelRequest := context.WithTimeout(context.Background(), oauthTokenTimeout)
+	authorization, err := requestDeviceAuthorization(requestCtx, oauthCfg)
+	cancelRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.DeviceLoginStatus{
+		State:                   models.DeviceLoginPending,
+		UserCode:                authorization.UserCode,
+		VerificationURI:         authorization.VerificationURI,
+		VerificationURIComplete: authorization.VerificationURIComplete,
+		ExpiresAt:               authorization.Expiry.Format(time.RFC3339),
+		Interval:                authorization.Interval,
+	}
+	if status.Interval <= 0 {
+		status.Interval = int64(defaultDevicePollInterval / time.Second)
+	}
+
+	pollCtx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	if s.cancelDevice != nil {
+		s.cancelDevice()
+	}
+	s.device = status
+	s.cancelDevice = cancel
+	s.mu.Unlock()
+
+	go s.pollDeviceLogin(pollCtx, oauthCfg, authorization, status)
+
+	result := *status
+	return &result, nil
+}
+
+// GetDeviceLoginStatus returns the latest device login, or nil when none was started.
+func (s *TokenService) GetDeviceLoginStatus() *models.DeviceLoginStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.device == nil {
+		return nil
+	}
+	result := *s.device
+	return &result
+}
+
+// CancelDeviceLogin stops polling for the pending device login.
+// It reports whether one was pending.
+func (s *TokenService) CancelDeviceLogin() bool {
+	s.mu.Lock()
+	cancel := s.cancelDevice
+	pending := s.device != nil && s.device.State == models.DeviceLoginPending
+	s.mu.Unlock()
+	if cancel == nil || !pending {
+		return false
+	}
+	cancel()
+	return true
+}
+
+func (s *TokenService) pollDeviceLogin(ctx context.Context, oauthCfg *oauth2.Config, authorization *oauth2.DeviceAuthResponse, status *models.DeviceLoginStatus) {
+	token, err := pollDeviceToken(ctx, oauthCfg, authorization, func(interval time.Duration) {
+		s.updateDeviceLogin(status, func(current *models.DeviceLoginStatus) {
+			current.Interval = int64(interval / time.Second)
+		})
+	})
+	if err == nil {
+		saveCtx, cancel := context.WithTimeout(context.Background(), oauthTokenTimeout)
+		err = completeOAuthLogin(saveCtx, token)
+		cancel()
+	}
+
+	s.updateDeviceLogin(status, func(current *models.DeviceLoginStatus) {
+		current.State, current.Error = deviceLoginOutcome(err)
+	})
+
+	s.mu.Lock()
+	if s.device == status {
+		s.cancelDevice = nil
+	}
+	s.mu.Unlock()
+}
+
+// updateDeviceLogin applies change to status and emits the result, unless a newer
+// login has replaced it.
+func (s *TokenService) updateDeviceLogin(status *models.DeviceLoginStatus, change func(*models.DeviceLoginStatus)) {
+	s.mu.Lock()
+	if s.device != status {
+		s.mu.Unlock()
+		return
+	}
+	change(status)
+	payload := *status
+	ctx := s.ctx
+	s.mu.Unlock()
+
+	if ctx != nil {
+		runtime.EventsEmit(ctx, deviceLoginEvent, payload)
+	}
+}
+
+func deviceLoginOutcome(err error) (string, string) {
+	var tokenErr *deviceTokenError
+	switch {
+	case err == nil:
+		return models.DeviceLoginSucceeded, ""
+	case errors.Is(err, context.Canceled):
+		return models.DeviceLoginCancelled, ""
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &tokenErr) && tokenErr.Code == "expired_token":
+		return models.DeviceLoginExpired, "验证码已过期，请重新发起登录"
+	case errors.As(err, &tokenErr) && tokenErr.Code == "access_denied":
+		return models.DeviceLoginFailed, "授权请求已被拒绝"
+	default:
+		return models.DeviceLoginFailed, err.Error()
+	}
+}
+
 // ClearOAuthToken removes OAuth token from keyring.
 func (s *TokenService) ClearOAuthToken() error {
 	return ClearOAuthToken()
 }
+
+// IsDemoMode reports whether the app is running against the built-in demo server.
+func (s *TokenService) IsDemoMode() bool {
+	return demoModeEnabled
+}
+
+// emitPortFallback tells the frontend which port the login callback moved to and who
+// holds the configured one.
+func (s *TokenService) emitPortFallback(fallback models.OAuthPortFallback) {
+	s.mu.Lock()
+	ctx := s.ctx
+	s.mu.Unlock()
+	if ctx != nil {
+		runtime.EventsEmit(ctx, oauthPortFallbackEvent, fallback)
+	}
+}
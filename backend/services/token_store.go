@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/zalando/go-keyring"
@@ -16,6 +17,28 @@ const (
 	oauthTokenKey = "oauth_token"
 )
 
+var (
+	accountChangedMu    sync.Mutex
+	accountChangedHooks []func()
+)
+
+// onAccountChanged registers a callback that runs after the OAuth token is removed or
+// another account becomes active, so services can drop data cached for the previous user.
+func onAccountChanged(hook func()) {
+	accountChangedMu.Lock()
+	accountChangedHooks = append(accountChangedHooks, hook)
+	accountChangedMu.Unlock()
+}
+
+func notifyAccountChanged() {
+	accountChangedMu.Lock()
+	hooks := append([]func(){}, accountChangedHooks...)
+	accountChangedMu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
 type storedOAuthToken struct {
 	AccessToken  string    `json:"access_token"`
 	TokenType    string    `json:"token_type,omitempty"`
@@ -49,8 +72,12 @@ func fromStoredOAuthToken(token *storedOAuthToken) *oauth2.Token {
 	}
 }
 
-// SaveOAuthToken stores an OAuth token in the OS keyring.
+// SaveOAuthToken stores the active account's OAuth token in the OS keyring.
 func SaveOAuthToken(token *oauth2.Token) error {
+	return writeOAuthToken(activeTokenKey(), token)
+}
+
+func writeOAuthToken(key string, token *oauth2.Token) error {
 	if token == nil || strings.TrimSpace(token.AccessToken) == "" {
 		return fmt.Errorf("oauth token is empty")
 	}
@@ -61,15 +88,19 @@ func SaveOAuthToken(token *oauth2.Token) error {
 		return fmt.Errorf("marshal oauth token: %w", err)
 	}
 
-	if err := keyring.Set(tokenService, oauthTokenKey, string(payload)); err != nil {
+	if err := keyring.Set(tokenService, key, string(payload)); err != nil {
 		return fmt.Errorf("save oauth token to keyring: %w", err)
 	}
 	return nil
 }
 
-// LoadOAuthToken loads the OAuth token from the OS keyring.
+// LoadOAuthToken loads the active account's OAuth token from the OS keyring.
 func LoadOAuthToken() (*oauth2.Token, error) {
-	raw, err := keyring.Get(tokenService, oauthTokenKey)
+	return readOAuthToken(activeTokenKey())
+}
+
+func readOAuthToken(key string) (*oauth2.Token, error) {
+	raw, err := keyring.Get(tokenService, key)
 	if err != nil {
 		return nil, err
 	}
@@ -94,13 +125,27 @@ func LoadOAuthToken() (*oauth2.Token, error) {
 	return fromStoredOAuthToken(&stored), nil
 }
 
-// ClearOAuthToken removes OAuth token from the OS keyring.
+// ClearOAuthToken removes the active account's OAuth token from the OS keyring and
+// signs the account out. Other accounts keep their tokens.
 func ClearOAuthToken() error {
-	err := keyring.Delete(tokenService, oauthTokenKey)
-	if err == nil || errors.Is(err, keyring.ErrNotFound) {
-		return nil
+	return clearOAuthTokenKey(activeTokenKey())
+}
+
+// clearOAuthTokenKey removes the token stored under key and signs its account out.
+// The active account is only deselected when key holds its token.
+func clearOAuthTokenKey(key string) error {
+	err := keyring.Delete(tokenService, key)
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("clear oauth token from keyring: %w", err)
 	}
-	return fmt.Errorf("clear oauth token from keyring: %w", err)
+	wasActive, err := signOutTokenKey(key)
+	if err != nil {
+		return err
+	}
+	if wasActive {
+		notifyAccountChanged()
+	}
+	return nil
 }
 
 // HasOAuthToken returns true when a token exists in the OS keyring.
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"loliashizuku/backend/apperror"
+	"loliashizuku/backend/models"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	trafficExportDateLayout = "2006-01-02"
+	trafficExportMaxDays    = 366
+)
+
+// ExportTraffic writes per-day, per-tunnel traffic for a date range as CSV or JSON.
+// When options.Path is empty a save dialog asks the user for the destination.
+func (s *CenterService) ExportTraffic(requestID string, options models.TrafficExportOptions) (*models.TrafficExportResult, error) {
+	format := strings.ToLower(strings.TrimSpace(options.Format))
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		return nil, apperror.New(apperror.CodeInvalidArgument).WithDetail("不支持的导出格式：" + options.Format)
+	}
+
+	startDate, endDate, err := parseTrafficExportRange(options.StartDate, options.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	days := int(time.Since(startDate).Hours()/24) + 1
+	if days > trafficExportMaxDays {
+		return nil, apperror.New(apperror.CodeInvalidArgument).WithDetail(fmt.Sprintf("导出范围超过 %d 天", trafficExportMaxDays))
+	}
+
+	result := &models.TrafficExportResult{
+		Format:    format,
+		StartDate: startDate.Format(trafficExportDateLayout),
+		EndDate:   endDate.Format(trafficExportDateLayout),
+	}
+
+	path := strings.TrimSpace(options.Path)
+	if path == "" {
+		path, err = s.askTrafficExportPath(result)
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			result.Cancelled = true
+			return result, nil
+		}
+	}
+
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+
+	daily, err := s.api.GetTrafficDaily(ctx, days)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := flattenDailyTraffic(daily, startDate, endDate)
+	switch format {
+	case "csv":
+		err = writeTrafficCSV(path, rows)
+	case "json":
+		err = writeTrafficJSON(path, models.TrafficExportDocument{
+			StartDate:   result.StartDate,
+			EndDate:     result.EndDate,
+			GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+			Rows:        rows,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Path = path
+	result.RowCount = len(rows)
+	return result, nil
+}
+
+func (s *CenterService) askTrafficExportPath(result *models.TrafficExportResult) (string, error) {
+	if s.appCtx == nil {
+		return "", apperror.New(apperror.CodeInvalidArgument).WithDetail("未指定导出路径")
+	}
+
+	filename := fmt.Sprintf("traffic_%s_%s.%s", result.StartDate, result.EndDate, result.Format)
+	filter := runtime.FileFilter{DisplayName: "CSV (*.csv)", Pattern: "*.csv"}
+	if result.Format == "json" {
+		filter = runtime.FileFilter{DisplayName: "JSON (*.json)", Pattern: "*.json"}
+	}
+
+	path, err := runtime.SaveFileDialog(s.appCtx, runtime.SaveDialogOptions{
+		Title:                "导出流量统计",
+		DefaultFilename:      filename,
+		Filters:              []runtime.FileFilter{filter},
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		return "", apperror.Wrap(apperror.CodeDialog, err)
+	}
+	return strings.TrimSpace(path), nil
+}
+
+func parseTrafficExportRange(rawStart, rawEnd string) (time.Time, time.Time, error) {
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+
+	endDate := today
+	if strings.TrimSpace(rawEnd) != "" {
+		parsed, err := time.ParseInLocation(trafficExportDateLayout, strings.TrimSpace(rawEnd), time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, apperror.New(apperror.CodeInvalidArgument).WithDetail("无效的结束日期：" + rawEnd)
+		}
+		endDate = parsed
+	}
+
+	startDate := endDate.AddDate(0, 0, -6)
+	if strings.TrimSpace(rawStart) != "" {
+		parsed, err := time.ParseInLocation(trafficExportDateLayout, strings.TrimSpace(rawStart), time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, apperror.New(apperror.CodeInvalidArgument).WithDetail("无效的开始日期：" + rawStart)
+		}
+		startDate = parsed
+	}
+
+	if endDate.After(today) {
+		endDate = today
+	}
+	if startDate.After(endDate) {
+		return time.Time{}, time.Time{}, apperror.New(apperror.CodeInvalidArgument).WithDetail("开始日期不能晚于结束日期")
+	}
+	return startDate, endDate, nil
+}
+
+// flattenDailyTraffic turns the nested daily stats into one row per date and tunnel,
+// keeping only dates inside [startDate, endDate].
+func flattenDailyTraffic(daily *models.DailyTrafficResponse, startDate, endDate time.Time) []models.TrafficExportRow {
+	rows := []models.TrafficExportRow{}
+	if daily == nil {
+		return rows
+	}
+
+	for _, stat := range daily.DailyStats {
+		date, ok := parseTrafficStatDate(stat.Date)
+		if !ok || date.Before(startDate) || date.After(endDate) {
+			continue
+		}
+		dateText := date.Format(trafficExportDateLayout)
+		for _, tunnel := range stat.TunnelStats {
+			rows = append(rows, models.TrafficExportRow{
+				Date:         dateText,
+				TunnelName:   strings.TrimSpace(tunnel.TunnelName),
+				Remark:       strings.TrimSpace(tunnel.Remark),
+				TotalTraffic: tunnel.TotalTraffic,
+			})
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Date != rows[j].Date {
+			return rows[i].Date < rows[j].Date
+		}
+		return rows[i].TunnelName < rows[j].TunnelName
+	})
+	return rows
+}
+
+func parseTrafficStatDate(raw string) (time.Time, bool) {
+	trimmed := strings.TrimSpace(raw)
+	if len(trimmed) < len(trafficExportDateLayout) {
+		return time.Time{}, false
+	}
+	date, err := time.ParseInLocation(trafficExportDateLayout, trimmed[:len(trafficExportDateLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+func writeTrafficCSV(path string, rows []models.TrafficExportRow) error {
+	if err := ensureDirs(filepath.Dir(path)); err != nil {
+		return apperror.Wrap(apperror.CodeStorage, err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return apperror.Wrap(apperror.CodeStorage, err)
+	}
+
+	writer := csv.NewWriter(file)
+	records := make([][]string, 0, len(rows)+1)
+	records = append(records, []string{"date", "tunnel", "remark", "bytes"})
+	for _, row := range rows {
+		records = append(records, []string{
+			row.Date,
+			csvSafeCell(row.TunnelName),
+			csvSafeCell(row.Remark),
+			strconv.FormatInt(row.TotalTraffic, 10),
+		})
+	}
+	if err := writer.WriteAll(records); err != nil {
+		_ = file.Close()
+		return apperror.Wrap(apperror.CodeStorage, err)
+	}
+	if err := file.Close(); err != nil {
+		return apperror.Wrap(apperror.CodeStorage, err)
+	}
+	return nil
+}
+
+// csvSafeCell prefixes values that spreadsheets would evaluate as a formula with a
+// single quote, so tunnel names and remarks are always shown as text.
+func csvSafeCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func writeTrafficJSON(path string, document models.TrafficExportDocument) error {
+	if err := ensureDirs(filepath.Dir(path)); err != nil {
+		return apperror.Wrap(apperror.CodeStorage, err)
+	}
+
+	payload, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return apperror.Wrap(apperror.CodeStorage, err)
+	}
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		return apperror.Wrap(apperror.CodeStorage, err)
+	}
+	return nil
+}
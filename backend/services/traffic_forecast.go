@@ -0,0 +1,273 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"loliashizuku/backend/models"
+)
+
+const (
+	trafficForecastDefaultDays = 14
+	trafficForecastMaxDays     = 90
+	trafficForecastMAWindow    = 7
+	trafficForecastHorizonDays = 365
+	// trafficForecastZ is the z-score of the reported confidence band (80%).
+	trafficForecastZ          = 1.2816
+	trafficForecastConfidence = 0.8
+)
+
+// GetTrafficForecast estimates when the traffic quota runs out at the current rate.
+// The daily burn rate is a moving average of recent days projected forward with the
+// linear trend of the whole window; the band uses the residual spread of that trend.
+func (s *CenterService) GetTrafficForecast(requestID string, days int) (*models.TrafficForecast, error) {
+	if days <= 0 {
+		days = trafficForecastDefaultDays
+	}
+	if days > trafficForecastMaxDays {
+		days = trafficForecastMaxDays
+	}
+
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+
+	daily, err := s.api.GetTrafficDaily(ctx, days)
+	if err != nil {
+		return nil, err
+	}
+	traffic, err := s.api.GetUserTrafficStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildTrafficForecast(time.Now(), days, daily, traffic), nil
+}
+
+func buildTrafficForecast(now time.Time, days int, daily *models.DailyTrafficResponse, traffic *models.UserTrafficData) *models.TrafficForecast {
+	forecast := &models.TrafficForecast{
+		GeneratedAt:      now.UTC().Format(time.RFC3339),
+		TrafficLimit:     traffic.TrafficLimit,
+		TrafficUsed:      traffic.TrafficUsed,
+		TrafficRemaining: traffic.TrafficRemaining,
+		Confidence:       trafficForecastConfidence,
+		HorizonDays:      trafficForecastHorizonDays,
+		Tunnels:          []models.TunnelBurnContribution{},
+	}
+	if forecast.TrafficRemaining <= 0 && forecast.TrafficLimit > 0 {
+		forecast.TrafficRemaining = max(forecast.TrafficLimit-forecast.TrafficUsed, 0)
+	}
+
+	stats := completedDailyStats(now, days, daily)
+	forecast.WindowDays = len(stats)
+	if len(stats) == 0 {
+		return forecast
+	}
+
+	series := make([]float64, len(stats))
+	for i, stat := range stats {
+		series[i] = float64(stat.TotalTraffic)
+	}
+
+	maWindow := min(trafficForecastMAWindow, len(series))
+	recent := series[len(series)-maWindow:]
+	forecast.MovingAverage = mean(recent)
+
+	slope, intercept := linearTrend(series)
+	forecast.TrendPerDay = slope
+	forecast.DailyBurnRate = math.Max(forecast.MovingAverage, 0)
+	spread := residualStdDev(series, slope, intercept)
+	bandRate := trafficForecastZ * spread / math.Sqrt(float64(maWindow))
+
+	forecast.Tunnels = tunnelBurnContributions(stats[len(stats)-maWindow:])
+
+	if forecast.TrafficLimit <= 0 && forecast.TrafficRemaining <= 0 {
+		// No quota information; only the burn rate is meaningful.
+		return forecast
+	}
+	if forecast.TrafficRemaining <= 0 {
+		forecast.WillExhaust = true
+		date := now.Format(trafficExportDateLayout)
+		forecast.ExhaustionDate, forecast.ExhaustionDateEarliest, forecast.ExhaustionDateLatest = date, date, date
+		return forecast
+	}
+
+	remaining := float64(forecast.TrafficRemaining)
+	daysMid, okMid := daysUntilExhausted(remaining, forecast.MovingAverage, slope)
+	daysLow, okLow := daysUntilExhausted(remaining, forecast.MovingAverage+bandRate, slope)
+	daysHigh, okHigh := daysUntilExhausted(remaining, forecast.MovingAverage-bandRate, slope)
+
+	forecast.WillExhaust = okMid
+	if okMid {
+		forecast.DaysRemaining = daysMid
+		forecast.ExhaustionDate = forecastDate(now, daysMid)
+	}
+	if okLow {
+		forecast.DaysRemainingLow = daysLow
+		forecast.ExhaustionDateEarliest = forecastDate(now, daysLow)
+	}
+	if okHigh {
+		forecast.DaysRemainingHigh = daysHigh
+		forecast.ExhaustionDateLatest = forecastDate(now, daysHigh)
+	} else {
+		forecast.DaysRemainingHigh = trafficForecastHorizonDays
+	}
+	return forecast
+}
+
+// completedDailyStats returns one stat per completed day of the window in date order,
+// dropping today's partial day unless it is the only data available. The API omits days
+// without traffic, so those are filled in with zero usage before anything is averaged.
+func completedDailyStats(now time.Time, days int, daily *models.DailyTrafficResponse) []models.DailyTrafficStat {
+	if daily == nil {
+		return nil
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := today.AddDate(0, 0, -max(days-1, 1))
+	byDate := map[string]*models.DailyTrafficStat{}
+	var todayStat *models.DailyTrafficStat
+	for _, stat := range daily.DailyStats {
+		parsed, ok := parseTrafficStatDate(stat.Date)
+		if !ok {
+			continue
+		}
+		date := time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, now.Location())
+		if !date.Before(today) {
+			if date.Equal(today) {
+				current := stat
+				todayStat = &current
+			}
+			continue
+		}
+		if date.Before(start) {
+			start = date
+		}
+
+		key := date.Format(trafficExportDateLayout)
+		if existing, ok := byDate[key]; ok {
+			existing.TotalTraffic += stat.TotalTraffic
+			existing.TunnelStats = append(existing.TunnelStats, stat.TunnelStats...)
+			continue
+		}
+		current := stat
+		current.Date = key
+		byDate[key] = &current
+	}
+	if len(byDate) == 0 {
+		if todayStat != nil {
+			return []models.DailyTrafficStat{*todayStat}
+		}
+		return nil
+	}
+
+	stats := make([]models.DailyTrafficStat, 0, len(byDate))
+	for date := start; date.Before(today); date = date.AddDate(0, 0, 1) {
+		key := date.Format(trafficExportDateLayout)
+		if stat, ok := byDate[key]; ok {
+			stats = append(stats, *stat)
+			continue
+		}
+		stats = append(stats, models.DailyTrafficStat{Date: key, TunnelStats: []models.DailyTunnelStat{}})
+	}
+	return stats
+}
+
+// daysUntilExhausted walks forward day by day with usage rate+slope*t and returns the
+// fractional number of days until remaining is used up, or false past the horizon.
+func daysUntilExhausted(remaining, rate, slope float64) (float64, bool) {
+	for day := 1; day <= trafficForecastHorizonDays; day++ {
+		usage := math.Max(rate+slope*float64(day), 0)
+		if usage <= 0 {
+			continue
+		}
+		if usage >= remaining {
+			return float64(day-1) + remaining/usage, true
+		}
+		remaining -= usage
+	}
+	return 0, false
+}
+
+func tunnelBurnContributions(stats []models.DailyTrafficStat) []models.TunnelBurnContribution {
+	totals := map[string]*models.TunnelBurnContribution{}
+	var grandTotal float64
+	for _, stat := range stats {
+		for _, tunnel := range stat.TunnelStats {
+			name := strings.TrimSpace(tunnel.TunnelName)
+			item, ok := totals[name]
+			if !ok {
+				item = &models.TunnelBurnContribution{TunnelName: name}
+				totals[name] = item
+			}
+			if remark := strings.TrimSpace(tunnel.Remark); remark != "" {
+				item.Remark = remark
+			}
+			item.DailyAverage += float64(tunnel.TotalTraffic)
+			grandTotal += float64(tunnel.TotalTraffic)
+		}
+	}
+
+	contributions := make([]models.TunnelBurnContribution, 0, len(totals))
+	for _, item := range totals {
+		if grandTotal > 0 {
+			item.Share = item.DailyAverage / grandTotal
+		}
+		item.DailyAverage /= float64(len(stats))
+		contributions = append(contributions, *item)
+	}
+	sort.Slice(contributions, func(i, j int) bool {
+		return contributions[i].DailyAverage > contributions[j].DailyAverage
+	})
+	return contributions
+}
+
+func linearTrend(series []float64) (float64, float64) {
+	n := float64(len(series))
+	if len(series) < 2 {
+		return 0, mean(series)
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range series {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, sumY / n
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	return slope, (sumY - slope*sumX) / n
+}
+
+func residualStdDev(series []float64, slope, intercept float64) float64 {
+	if len(series) < 3 {
+		return 0
+	}
+	var sum float64
+	for i, y := range series {
+		residual := y - (intercept + slope*float64(i))
+		sum += residual * residual
+	}
+	return math.Sqrt(sum / float64(len(series)-2))
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+func forecastDate(now time.Time, days float64) string {
+	return now.Add(time.Duration(days * float64(24*time.Hour))).Format(trafficExportDateLayout)
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"loliashizuku/backend/api"
+	"loliashizuku/backend/history"
+	"loliashizuku/backend/models"
+)
+
+const (
+	trafficHistorySnapshotInterval = 6 * time.Hour
+	trafficHistoryInitialDelay     = 30 * time.Second
+	trafficHistorySnapshotDays     = 30
+)
+
+// TrafficHistoryService periodically snapshots traffic statistics into a local
+// database so history beyond the API's rolling window is kept.
+type TrafficHistoryService struct {
+	center *CenterService
+
+	mu       sync.Mutex
+	store    *history.Store
+	loopOnce sync.Once
+}
+
+// NewTrafficHistoryService creates a new TrafficHistoryService.
+func NewTrafficHistoryService(center *CenterService) *TrafficHistoryService {
+	return &TrafficHistoryService{center: center}
+}
+
+// Start launches the periodic snapshot loop. It stops when ctx is done.
+func (s *TrafficHistoryService) Start(ctx context.Context) {
+	s.loopOnce.Do(func() {
+		go s.loopSnapshot(ctx)
+	})
+}
+
+// Shutdown closes the history database.
+func (s *TrafficHistoryService) Shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.store != nil {
+		_ = s.store.Close()
+		s.store = nil
+	}
+}
+
+// SnapshotTrafficHistory captures the current traffic statistics immediately.
+func (s *TrafficHistoryService) SnapshotTrafficHistory(requestID string) (*models.TrafficHistorySnapshotResult, error) {
+	ctx, done := s.center.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.snapshot(ctx)
+}
+
+// GetMonthlyTrafficTotals returns per-month totals between two "YYYY-MM" months (inclusive).
+func (s *TrafficHistoryService) GetMonthlyTrafficTotals(requestID string, startMonth, endMonth string) ([]models.MonthlyTrafficTotal, error) {
+	store, userID, err := s.storeForCurrentUser(requestID)
+	if err != nil {
+		return nil, err
+	}
+	return store.MonthlyTotals(userID, startMonth, endMonth)
+}
+
+// GetTunnelTrafficHistory returns per-day traffic of a tunnel (or all tunnels when
+// tunnelName is empty) between two "YYYY-MM-DD" dates (inclusive).
+func (s *TrafficHistoryService) GetTunnelTrafficHistory(requestID string, tunnelName, startDate, endDate string) ([]models.TunnelTrafficHistory, error) {
+	store, userID, err := s.storeForCurrentUser(requestID)
+	if err != nil {
+		return nil, err
+	}
+	return store.TunnelHistory(userID, tunnelName, startDate, endDate)
+}
+
+func (s *TrafficHistoryService) loopSnapshot(ctx context.Context) {
+	timer := time.NewTimer(trafficHistoryInitialDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if has, err := HasOAuthToken(); err == nil && has {
+			snapshotCtx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+			_, _ = s.snapshot(snapshotCtx)
+			cancel()
+		}
+		timer.Reset(trafficHistorySnapshotInterval)
+	}
+}
+
+func (s *TrafficHistoryService) snapshot(ctx context.Context) (*models.TrafficHistorySnapshotResult, error) {
+	store, err := s.openStore()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.center.api.GetUserInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	daily, err := s.center.api.GetTrafficDaily(ctx, trafficHistorySnapshotDays)
+	if err != nil {
+		return nil, err
+	}
+	tunnels, err := s.center.api.GetTrafficTunnels(ctx, trafficHistorySnapshotDays)
+	if err != nil {
+		return nil, err
+	}
+	traffic, err := s.center.api.GetUserTrafficStats(api.BypassCache(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := &models.TrafficHistorySnapshotResult{
+		UserID:     user.ID,
+		CapturedAt: now.UTC().Format(time.RFC3339),
+	}
+	if result.DailyEntries, err = store.PutDailyTraffic(user.ID, daily); err != nil {
+		return nil, err
+	}
+	if result.TunnelEntries, err = store.PutTunnelTotals(user.ID, now, tunnels); err != nil {
+		return nil, err
+	}
+	if err := store.PutUserTraffic(user.ID, now, traffic); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *TrafficHistoryService) storeForCurrentUser(requestID string) (*history.Store, int64, error) {
+	store, err := s.openStore()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, done := s.center.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	user, err := s.center.api.GetUserInfo(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return store, user.ID, nil
+}
+
+func (s *TrafficHistoryService) openStore() (*history.Store, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store != nil {
+		return s.store, nil
+	}
+
+	dataDir, err := userDataDir()
+	if err != nil {
+		return nil, err
+	}
+	store, err := history.Open(filepath.Join(dataDir, "history", "traffic.db"))
+	if err != nil {
+		return nil, err
+	}
+	s.store = store
+	return store, nil
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"loliashizuku/backend/api"
+	"loliashizuku/backend/apperror"
+	"loliashizuku/backend/models"
+	"loliashizuku/backend/tunnelfile"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ExportTunnels writes the account's tunnels to a YAML or TOML file. Tunnel tokens are
+// never included. When options.Path is empty a save dialog asks for the destination.
+func (s *CenterService) ExportTunnels(requestID string, options models.TunnelExportOptions) (*models.TunnelExportResult, error) {
+	format, err := tunnelfile.ResolveFormat(options.Format, options.Path)
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeInvalidArgument, err)
+	}
+	result := &models.TunnelExportResult{Format: format}
+
+	path := strings.TrimSpace(options.Path)
+	if path == "" {
+		path, err = s.askTunnelFilePath(true, format)
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			result.Cancelled = true
+			return result, nil
+		}
+	}
+
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+
+	definitions, _, err := s.currentTunnelDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := tunnelfile.Encode(format, tunnelfile.Document{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Tunnels:    definitions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureDirs(filepath.Dir(path)); err != nil {
+		return nil, apperror.Wrap(apperror.CodeStorage, err)
+	}
+	if err := os.WriteFile(path, payload, 0o600); err != nil {
+		return nil, apperror.Wrap(apperror.CodeStorage, err)
+	}
+
+	result.Path = path
+	result.TunnelCount = len(definitions)
+	return result, nil
+}
+
+// PreviewTunnelImport compares a tunnel file with the account and lists the creates,
+// updates and deletes that ApplyTunnelImport would make. When options.Path is empty an
+// open dialog asks for the file.
+func (s *CenterService) PreviewTunnelImport(requestID string, options models.TunnelImportOptions) (*models.TunnelImportPlan, error) {
+	if strings.TrimSpace(options.Path) == "" {
+		path, err := s.askTunnelFilePath(false, "")
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			return &models.TunnelImportPlan{Cancelled: true}, nil
+		}
+		options.Path = path
+	}
+
+	ctx, done := s.requests.begin(requestID, defaultRequestTimeout)
+	defer done()
+	return s.planTunnelImport(ctx, options)
+}
+
+// ApplyTunnelImport re-reads the file, diffs it against the account again and applies
+// the changes: deletes first to free ports and domains, then updates, then creates.
+// The recomputed plan must still match options.PlanDigest from the preview, so the
+// changes made are the ones the user approved. A failing change does not stop the
+// others; each change gets its own timeout.
+func (s *CenterService) ApplyTunnelImport(requestID string, options models.TunnelImportOptions) (*models.TunnelImportResult, error) {
+	if strings.TrimSpace(options.Path) == "" {
+		return nil, apperror.New(apperror.CodeInvalidArgument).WithDetail("未指定隧道文件")
+	}
+	if strings.TrimSpace(options.PlanDigest) == "" {
+		return nil, apperror.New(apperror.CodeInvalidArgument).WithDetail("缺少预览的导入计划")
+	}
+
+	ctx, done := s.requests.beginUnbounded(requestID)
+	defer done()
+
+	planCtx, cancelPlan := context.WithTimeout(ctx, defaultRequestTimeout)
+	plan, err := s.planTunnelImport(planCtx, options)
+	cancelPlan()
+	if err != nil {
+		return nil, err
+	}
+	if plan.Digest != strings.TrimSpace(options.PlanDigest) {
+		return nil, apperror.New(apperror.CodePlanChanged)
+	}
+
+	result := &models.TunnelImportResult{
+		Plan:    *plan,
+		Results: []models.TunnelChangeResult{},
+	}
+	apply := func(change models.TunnelChange, call func(ctx context.Context) error) {
+		changeCtx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+		defer cancel()
+
+		outcome := models.TunnelChangeResult{TunnelChange: change}
+		if err := call(changeCtx); err != nil {
+			outcome.Error = err.Error()
+			result.Failed++
+		} else {
+			outcome.Success = true
+			result.Applied++
+		}
+		result.Results = append(result.Results, outcome)
+	}
+
+	for _, change := range plan.Deletes {
+		apply(change, func(ctx context.Context) error { return s.api.DeleteTunnel(ctx, change.Name) })
+	}
+	for _, change := range plan.Updates {
+		apply(change, func(ctx context.Context) error { return s.api.UpdateTunnel(ctx, change.Name, updateTunnelSpec(change)) })
+	}
+	for _, change := range plan.Creates {
+		apply(change, func(ctx context.Context) error { return s.api.CreateTunnel(ctx, change.After.Spec()) })
+	}
+	return result, nil
+}
+
+// updateTunnelSpec returns the spec sent for an update. A file without remote_port means
+// "leave it alone", so the current port is sent rather than none, which the update would drop.
+func updateTunnelSpec(change models.TunnelChange) models.TunnelSpec {
+	spec := change.After.Spec()
+	if spec.RemotePort == 0 && change.Before != nil {
+		spec.RemotePort = change.Before.RemotePort
+	}
+	return spec
+}
+
+func (s *CenterService) planTunnelImport(ctx context.Context, options models.TunnelImportOptions) (*models.TunnelImportPlan, error) {
+	path := strings.TrimSpace(options.Path)
+	format, err := tunnelfile.ResolveFormat(options.Format, path)
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeInvalidArgument, err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeStorage, err)
+	}
+	doc, err := tunnelfile.Decode(format, raw)
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeInvalidArgument, err)
+	}
+	if options.Prune && len(doc.Tunnels) == 0 {
+		return nil, apperror.New(apperror.CodeInvalidArgument).WithDetail("隧道文件为空，清理模式会删除全部隧道")
+	}
+
+	current, nodes, err := s.currentTunnelDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := resolveTunnelNodes(doc.Tunnels, nodes)
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeInvalidArgument, err)
+	}
+
+	plan := tunnelfile.Diff(current, desired, options.Prune)
+	plan.Path = path
+	plan.Format = format
+	plan.Digest = tunnelfile.Digest(plan)
+	return &plan, nil
+}
+
+// currentTunnelDefinitions returns the account's tunnels as file definitions, plus the
+// node list. Tunnels are always fetched fresh because imports are planned against them.
+func (s *CenterService) currentTunnelDefinitions(ctx context.Context) ([]models.TunnelDefinition, []models.NodeItem, error) {
+	tunnels, err := s.api.ListAllTunnels(api.BypassCache(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes, err := s.api.GetNodes(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodeNames := make(map[int64]string, len(nodes.Nodes))
+	for _, node := range nodes.Nodes {
+		nodeNames[node.ID] = node.Name
+	}
+	definitions := make([]models.TunnelDefinition, 0, len(tunnels.List))
+	for _, tunnel := range tunnels.List {
+		definitions = append(definitions, tunnelfile.Normalize(models.TunnelDefinition{
+			Name:         tunnel.Name,
+			Type:         tunnel.Type,
+			NodeID:       tunnel.NodeID,
+			NodeName:     nodeNames[tunnel.NodeID],
+			LocalIP:      tunnel.LocalIP,
+			LocalPort:    tunnel.LocalPort,
+			RemotePort:   tunnel.RemotePort,
+			CustomDomain: tunnel.CustomDomain,
+			Remark:       tunnel.Remark,
+		}))
+	}
+	return definitions, nodes.Nodes, nil
+}
+
+// resolveTunnelNodes fills NodeID from NodeName where the file only names the node,
+// and checks that every node exists.
+func resolveTunnelNodes(definitions []models.TunnelDefinition, nodes []models.NodeItem) ([]models.TunnelDefinition, error) {
+	byID := make(map[int64]models.NodeItem, len(nodes))
+	for _, node := range nodes {
+		byID[node.ID] = node
+	}
+
+	resolved := make([]models.TunnelDefinition, len(definitions))
+	for i, def := range definitions {
+		if def.NodeID <= 0 {
+			for _, node := range nodes {
+				if strings.EqualFold(strings.TrimSpace(node.Name), def.NodeName) {
+					def.NodeID = node.ID
+					break
+				}
+			}
+			if def.NodeID <= 0 {
+				return nil, fmt.Errorf("tunnel %q: node %q not found", def.Name, def.NodeName)
+			}
+		}
+		node, ok := byID[def.NodeID]
+		if !ok {
+			return nil, fmt.Errorf("tunnel %q: node %d not found", def.Name, def.NodeID)
+		}
+		def.NodeName = node.Name
+		resolved[i] = def
+	}
+	return resolved, nil
+}
+
+func (s *CenterService) askTunnelFilePath(save bool, format string) (string, error) {
+	if s.appCtx == nil {
+		return "", apperror.New(apperror.CodeInvalidArgument).WithDetail("未指定隧道文件路径")
+	}
+
+	filters := []runtime.FileFilter{
+		{DisplayName: "YAML (*.yaml, *.yml)", Pattern: "*.yaml;*.yml"},
+		{DisplayName: "TOML (*.toml)", Pattern: "*.toml"},
+	}
+	if !save {
+		path, err := runtime.OpenFileDialog(s.appCtx, runtime.OpenDialogOptions{
+			Title:   "导入隧道",
+			Filters: filters,
+		})
+		if err != nil {
+			return "", apperror.Wrap(apperror.CodeDialog, err)
+		}
+		return strings.TrimSpace(path), nil
+	}
+
+	if format == tunnelfile.FormatTOML {
+		filters = filters[1:]
+	} else {
+		filters = filters[:1]
+	}
+	path, err := runtime.SaveFileDialog(s.appCtx, runtime.SaveDialogOptions{
+		Title:                "导出隧道",
+		DefaultFilename:      fmt.Sprintf("tunnels_%s.%s", time.Now().Format(trafficExportDateLayout), format),
+		Filters:              filters,
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		return "", apperror.Wrap(apperror.CodeDialog, err)
+	}
+	return strings.TrimSpace(path), nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"loliashizuku/backend/models"
+)
+
+const (
+	tunnelQueryDefaultLimit       = 50
+	tunnelQueryMaxLimit           = 200
+	tunnelQueryDefaultTrafficDays = 7
+)
+
+func normalizeTunnelQuery(query models.TunnelQuery) models.TunnelQuery {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.Limit <= 0 {
+		query.Limit = tunnelQueryDefaultLimit
+	}
+	query.Limit = min(query.Limit, tunnelQueryMaxLimit)
+	query.SortBy = strings.ToLower(strings.TrimSpace(query.SortBy))
+	if query.Days <= 0 && query.SortBy == models.TunnelSortTraffic {
+		query.Days = tunnelQueryDefaultTrafficDays
+	}
+	return query
+}
+
+// filterTunnels returns the tunnels matching every filter of query, keeping their order.
+func filterTunnels(tunnels []models.TunnelItem, query models.TunnelQuery) []models.TunnelItem {
+	words := strings.Fields(strings.ToLower(query.Search))
+	types := lowerSet(query.Types)
+	statuses := lowerSet(query.Statuses)
+	nodeIDs := make(map[int64]struct{}, len(query.NodeIDs))
+	for _, id := range query.NodeIDs {
+		nodeIDs[id] = struct{}{}
+	}
+
+	matched := make([]models.TunnelItem, 0, len(tunnels))
+	for _, tunnel := range tunnels {
+		if len(types) > 0 && !containsKey(types, strings.ToLower(strings.TrimSpace(tunnel.Type))) {
+			continue
+		}
+		if len(statuses) > 0 && !containsKey(statuses, strings.ToLower(strings.TrimSpace(tunnel.Status))) {
+			continue
+		}
+		if len(nodeIDs) > 0 {
+			if _, ok := nodeIDs[tunnel.NodeID]; !ok {
+				continue
+			}
+		}
+		if !matchesTunnelSearch(tunnel, words) {
+			continue
+		}
+		matched = append(matched, tunnel)
+	}
+	return matched
+}
+
+func matchesTunnelSearch(tunnel models.TunnelItem, words []string) bool {
+	if len(words) == 0 {
+		return true
+	}
+	haystack := strings.ToLower(strings.Join([]string{tunnel.Name, tunnel.Remark, tunnel.CustomDomain}, "\n"))
+	for _, word := range words {
+		if !strings.Contains(haystack, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortTunnels orders tunnels in place. Ties fall back to the tunnel ID so pages are stable.
+func sortTunnels(tunnels []models.TunnelItem, sortBy string, sortDesc *bool) {
+	var less func(a, b models.TunnelItem) int
+	desc := false
+	switch sortBy {
+	case models.TunnelSortTraffic:
+		desc = true
+		less = func(a, b models.TunnelItem) int { return compareInt64(a.TotalTraffic, b.TotalTraffic) }
+	case models.TunnelSortName:
+		less = func(a, b models.TunnelItem) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+	case models.TunnelSortCreated:
+		desc = true
+		less = func(a, b models.TunnelItem) int { return compareTunnelCreated(a, b) }
+	default:
+		return
+	}
+	if sortDesc != nil {
+		desc = *sortDesc
+	}
+
+	sort.SliceStable(tunnels, func(i, j int) bool {
+		order := less(tunnels[i], tunnels[j])
+		if order == 0 {
+			return tunnels[i].ID < tunnels[j].ID
+		}
+		if desc {
+			return order > 0
+		}
+		return order < 0
+	})
+}
+
+// compareTunnelCreated compares creation times, falling back to the ID (which grows
+// with creation) when the API omits created_at.
+func compareTunnelCreated(a, b models.TunnelItem) int {
+	at, aErr := time.Parse(time.RFC3339, a.CreatedAt)
+	bt, bErr := time.Parse(time.RFC3339, b.CreatedAt)
+	if aErr == nil && bErr == nil && !at.Equal(bt) {
+		return at.Compare(bt)
+	}
+	return compareInt64(a.ID, b.ID)
+}
+
+func paginateTunnels(tunnels []models.TunnelItem, page, limit int) *models.TunnelOverviewData {
+	total := len(tunnels)
+	start := min((page-1)*limit, total)
+	end := min(start+limit, total)
+
+	list := make([]models.TunnelItem, end-start)
+	copy(list, tunnels[start:end])
+	return &models.TunnelOverviewData{
+		List:      list,
+		Page:      int64(page),
+		Limit:     int64(limit),
+		Total:     int64(total),
+		TotalPage: int64((total + limit - 1) / limit),
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func lowerSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			set[value] = struct{}{}
+		}
+	}
+	return set
+}
+
+func containsKey(set map[string]struct{}, key string) bool {
+	_, ok := set[key]
+	return ok
+}
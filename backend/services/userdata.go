@@ -0,0 +1,23 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// userDataRoot overrides the userdata directory when set. Demo mode points it at a
+// separate directory so demo data never mixes with the real account's.
+var userDataRoot string
+
+// userDataDir returns the directory holding per-user application data.
+func userDataDir() (string, error) {
+	if userDataRoot != "" {
+		return userDataRoot, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("get config dir: %w", err)
+	}
+	return filepath.Join(configDir, "LoliaShizuku", "userdata"), nil
+}
@@ -0,0 +1,238 @@
+// Package tlstrust builds TLS client settings that trust extra CA bundles and enforce
+// SPKI pins for a chosen host.
+package tlstrust
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"loliashizuku/backend/config"
+	"loliashizuku/backend/models"
+)
+
+const pinPrefix = "sha256/"
+
+// PinError is returned when a pinned host presents a chain without any pinned key.
+// Chain holds the certificates the server presented, leaf first.
+type PinError struct {
+	Host  string
+	Chain []models.CertificateInfo
+}
+
+func (e *PinError) Error() string {
+	return fmt.Sprintf("tls verify %s: no certificate in the chain matches the configured SPKI pins", e.Host)
+}
+
+// LoadError is returned for connections to a pinned host while the TLS settings
+// cannot be loaded, e.g. because a CA file was deleted.
+type LoadError struct {
+	Host string
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("tls verify %s: pinned TLS settings could not be loaded: %v", e.Host, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// ClientConfig returns a tls.Config that trusts the system roots plus settings.CAFiles
+// and, for pinnedHost, additionally requires a certificate whose key matches a pin.
+// When the settings cannot be loaded the returned config uses the system roots, except
+// that it fails closed for pinnedHost if pins are configured.
+func ClientConfig(settings config.TLSConfig, pinnedHost string) (*tls.Config, error) {
+	tlsConfig, err := clientConfig(settings, pinnedHost)
+	host := strings.TrimSpace(pinnedHost)
+	if err != nil && len(settings.CenterPins) > 0 && host != "" {
+		loadErr := &LoadError{Host: host, Err: err}
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if isPinnedHost(state, host) {
+				return loadErr
+			}
+			return nil
+		}
+	}
+	return tlsConfig, err
+}
+
+func clientConfig(settings config.TLSConfig, pinnedHost string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(settings.CAFiles) > 0 {
+		roots, err := LoadRoots(settings.CAFiles)
+		if err != nil {
+			return tlsConfig, err
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	pins := map[string]struct{}{}
+	for _, raw := range settings.CenterPins {
+		pin, err := ParsePin(raw)
+		if err != nil {
+			return tlsConfig, err
+		}
+		pins[pin] = struct{}{}
+	}
+	pinnedHost = strings.TrimSpace(pinnedHost)
+	if len(pins) == 0 || pinnedHost == "" {
+		return tlsConfig, nil
+	}
+
+	// VerifyConnection runs after the standard chain and hostname verification.
+	tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+		if !isPinnedHost(state, pinnedHost) {
+			return nil
+		}
+		for _, chain := range state.VerifiedChains {
+			for _, cert := range chain {
+				if _, ok := pins[SPKIPin(cert)]; ok {
+					return nil
+				}
+			}
+		}
+		return &PinError{Host: pinnedHost, Chain: DescribeChain(state.PeerCertificates)}
+	}
+	return tlsConfig, nil
+}
+
+// isPinnedHost reports whether state belongs to a connection to host. No SNI is sent
+// for IP addresses, so an IP host is matched against the verified leaf's IP SANs.
+func isPinnedHost(state tls.ConnectionState, host string) bool {
+	if state.ServerName != "" {
+		return strings.EqualFold(state.ServerName, host)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || len(state.PeerCertificates) == 0 {
+		return false
+	}
+	for _, certIP := range state.PeerCertificates[0].IPAddresses {
+		if certIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that every CA file holds at least one certificate and every pin parses.
+func Validate(settings config.TLSConfig) (config.TLSConfig, error) {
+	normalized := config.TLSConfig{CAFiles: []string{}, CenterPins: []string{}}
+	for _, path := range settings.CAFiles {
+		if path = strings.TrimSpace(path); path != "" {
+			normalized.CAFiles = append(normalized.CAFiles, path)
+		}
+	}
+	for _, raw := range settings.CenterPins {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		pin, err := ParsePin(raw)
+		if err != nil {
+			return settings, err
+		}
+		normalized.CenterPins = append(normalized.CenterPins, pin)
+	}
+	if _, err := LoadRoots(normalized.CAFiles); err != nil {
+		return settings, err
+	}
+	return normalized, nil
+}
+
+// LoadRoots returns the system roots plus the certificates in files.
+func LoadRoots(files []string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("ca file %s contains no PEM certificates", path)
+		}
+	}
+	return pool, nil
+}
+
+// SPKIPin returns the pin of cert's public key in sha256/<base64> form.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ParsePin normalizes a pin given as sha256/<base64>, bare base64 or hex.
+func ParsePin(raw string) (string, error) {
+	value := strings.TrimSpace(raw)
+	if strings.HasPrefix(strings.ToLower(value), pinPrefix) {
+		value = value[len(pinPrefix):]
+	}
+	if sum, err := base64.StdEncoding.DecodeString(value); err == nil && len(sum) == sha256.Size {
+		return pinPrefix + base64.StdEncoding.EncodeToString(sum), nil
+	}
+	if sum, err := hex.DecodeString(strings.ReplaceAll(value, ":", "")); err == nil && len(sum) == sha256.Size {
+		return pinPrefix + base64.StdEncoding.EncodeToString(sum), nil
+	}
+	return "", fmt.Errorf("invalid SPKI pin %q, expected sha256/<base64>", raw)
+}
+
+// DescribeChain summarizes certs for display.
+func DescribeChain(certs []*x509.Certificate) []models.CertificateInfo {
+	chain := make([]models.CertificateInfo, 0, len(certs))
+	for _, cert := range certs {
+		sum := sha256.Sum256(cert.Raw)
+		chain = append(chain, models.CertificateInfo{
+			Subject:      cert.Subject.String(),
+			Issuer:       cert.Issuer.String(),
+			SerialNumber: cert.SerialNumber.String(),
+			NotBefore:    cert.NotBefore.UTC().Format(time.RFC3339),
+			NotAfter:     cert.NotAfter.UTC().Format(time.RFC3339),
+			DNSNames:     cert.DNSNames,
+			IsCA:         cert.IsCA,
+			SHA256:       hex.EncodeToString(sum[:]),
+			SPKIPin:      SPKIPin(cert),
+		})
+	}
+	return chain
+}
+
+// ChainFromError returns the certificate chain and host of a rejected TLS connection in err.
+func ChainFromError(err error) ([]models.CertificateInfo, string, bool) {
+	host := ""
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+			host = u.Hostname()
+		}
+	}
+
+	var pinErr *PinError
+	if errors.As(err, &pinErr) {
+		return pinErr.Chain, pinErr.Host, true
+	}
+	var loadErr *LoadError
+	if errors.As(err, &loadErr) {
+		return nil, loadErr.Host, true
+	}
+	var verifyErr *tls.CertificateVerificationError
+	if errors.As(err, &verifyErr) {
+		var hostErr x509.HostnameError
+		if host == "" && errors.As(err, &hostErr) {
+			host = hostErr.Host
+		}
+		return DescribeChain(verifyErr.UnverifiedCertificates), host, true
+	}
+	return nil, "", false
+}
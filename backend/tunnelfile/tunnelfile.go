@@ -0,0 +1,267 @@
+// Package tunnelfile reads and writes declarative tunnel files and diffs them
+// against the tunnels of an account.
+package tunnelfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"loliashizuku/backend/models"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported file formats.
+const (
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// CurrentVersion is the file version written by Encode.
+const CurrentVersion = 1
+
+// Document is the top-level structure of a tunnel file.
+type Document struct {
+	Version    int                       `yaml:"version" toml:"version"`
+	ExportedAt string                    `yaml:"exported_at,omitempty" toml:"exported_at,omitempty"`
+	Tunnels    []models.TunnelDefinition `yaml:"tunnels" toml:"tunnels"`
+}
+
+// ResolveFormat normalizes format, or derives it from the path extension when empty.
+func ResolveFormat(format, path string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".toml":
+			format = FormatTOML
+		default:
+			format = FormatYAML
+		}
+	}
+	switch format {
+	case "yml", FormatYAML:
+		return FormatYAML, nil
+	case FormatTOML:
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("unsupported tunnel file format %q", format)
+	}
+}
+
+// Encode serializes doc in the given format.
+func Encode(format string, doc Document) ([]byte, error) {
+	if doc.Version == 0 {
+		doc.Version = CurrentVersion
+	}
+	var buf bytes.Buffer
+	switch format {
+	case FormatYAML:
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("encode yaml: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("encode yaml: %w", err)
+		}
+	case FormatTOML:
+		encoder := toml.NewEncoder(&buf)
+		encoder.Indent = ""
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("encode toml: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported tunnel file format %q", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode parses a tunnel file and validates its definitions.
+func Decode(format string, data []byte) (Document, error) {
+	var doc Document
+	switch format {
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&doc); err != nil {
+			return Document{}, fmt.Errorf("decode yaml: %w", err)
+		}
+	case FormatTOML:
+		meta, err := toml.Decode(string(data), &doc)
+		if err != nil {
+			return Document{}, fmt.Errorf("decode toml: %w", err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return Document{}, fmt.Errorf("decode toml: unknown key %q", undecoded[0].String())
+		}
+	default:
+		return Document{}, fmt.Errorf("unsupported tunnel file format %q", format)
+	}
+
+	if doc.Version > CurrentVersion {
+		return Document{}, fmt.Errorf("tunnel file version %d is newer than supported version %d", doc.Version, CurrentVersion)
+	}
+	for i := range doc.Tunnels {
+		doc.Tunnels[i] = Normalize(doc.Tunnels[i])
+	}
+	if err := validate(doc.Tunnels); err != nil {
+		return Document{}, err
+	}
+	return doc, nil
+}
+
+// Normalize trims text fields and lowercases the type so definitions compare reliably.
+func Normalize(def models.TunnelDefinition) models.TunnelDefinition {
+	def.Name = strings.TrimSpace(def.Name)
+	def.Type = strings.ToLower(strings.TrimSpace(def.Type))
+	def.NodeName = strings.TrimSpace(def.NodeName)
+	def.LocalIP = strings.TrimSpace(def.LocalIP)
+	def.CustomDomain = strings.TrimSpace(def.CustomDomain)
+	def.Remark = strings.TrimSpace(def.Remark)
+	return def
+}
+
+func validate(defs []models.TunnelDefinition) error {
+	seen := make(map[string]struct{}, len(defs))
+	for i, def := range defs {
+		if def.Name == "" {
+			return fmt.Errorf("tunnel #%d: name is required", i+1)
+		}
+		if _, ok := seen[def.Name]; ok {
+			return fmt.Errorf("tunnel %q: duplicate name", def.Name)
+		}
+		seen[def.Name] = struct{}{}
+
+		if def.Type == "" {
+			return fmt.Errorf("tunnel %q: type is required", def.Name)
+		}
+		if def.NodeID <= 0 && def.NodeName == "" {
+			return fmt.Errorf("tunnel %q: node_id or node_name is required", def.Name)
+		}
+		if def.LocalPort <= 0 || def.LocalPort > 65535 {
+			return fmt.Errorf("tunnel %q: local_port %d is out of range", def.Name, def.LocalPort)
+		}
+		if def.RemotePort < 0 || def.RemotePort > 65535 {
+			return fmt.Errorf("tunnel %q: remote_port %d is out of range", def.Name, def.RemotePort)
+		}
+	}
+	return nil
+}
+
+// Diff compares the desired definitions with the account's current ones. Tunnels only
+// present in current are returned as deletes when prune is set and as untracked otherwise.
+func Diff(current, desired []models.TunnelDefinition, prune bool) models.TunnelImportPlan {
+	plan := models.TunnelImportPlan{
+		Creates:   []models.TunnelChange{},
+		Updates:   []models.TunnelChange{},
+		Deletes:   []models.TunnelChange{},
+		Unchanged: []string{},
+		Untracked: []string{},
+	}
+
+	currentByName := make(map[string]models.TunnelDefinition, len(current))
+	for _, def := range current {
+		def = Normalize(def)
+		currentByName[def.Name] = def
+	}
+
+	wanted := make(map[string]struct{}, len(desired))
+	for _, def := range desired {
+		after := Normalize(def)
+		wanted[after.Name] = struct{}{}
+
+		before, exists := currentByName[after.Name]
+		if !exists {
+			plan.Creates = append(plan.Creates, models.TunnelChange{
+				Action: models.TunnelChangeCreate,
+				Name:   after.Name,
+				After:  &after,
+			})
+			continue
+		}
+		if fields := changedFields(before, after); len(fields) > 0 {
+			before := before
+			plan.Updates = append(plan.Updates, models.TunnelChange{
+				Action: models.TunnelChangeUpdate,
+				Name:   after.Name,
+				Before: &before,
+				After:  &after,
+				Fields: fields,
+			})
+			continue
+		}
+		plan.Unchanged = append(plan.Unchanged, after.Name)
+	}
+
+	for _, def := range current {
+		before := Normalize(def)
+		if _, ok := wanted[before.Name]; ok {
+			continue
+		}
+		if !prune {
+			plan.Untracked = append(plan.Untracked, before.Name)
+			continue
+		}
+		plan.Deletes = append(plan.Deletes, models.TunnelChange{
+			Action: models.TunnelChangeDelete,
+			Name:   before.Name,
+			Before: &before,
+		})
+	}
+
+	sortChanges(plan.Creates)
+	sortChanges(plan.Updates)
+	sortChanges(plan.Deletes)
+	sort.Strings(plan.Unchanged)
+	sort.Strings(plan.Untracked)
+	return plan
+}
+
+// changedFields lists the fields that differ. NodeName is informative and ignored, and
+// a zero remote port means "assigned by the server" so it never counts as a change.
+func changedFields(before, after models.TunnelDefinition) []string {
+	var fields []string
+	if before.Type != after.Type {
+		fields = append(fields, "type")
+	}
+	if before.NodeID != after.NodeID {
+		fields = append(fields, "node_id")
+	}
+	if before.LocalIP != after.LocalIP {
+		fields = append(fields, "local_ip")
+	}
+	if before.LocalPort != after.LocalPort {
+		fields = append(fields, "local_port")
+	}
+	if after.RemotePort != 0 && before.RemotePort != after.RemotePort {
+		fields = append(fields, "remote_port")
+	}
+	if !strings.EqualFold(before.CustomDomain, after.CustomDomain) {
+		fields = append(fields, "custom_domain")
+	}
+	if before.Remark != after.Remark {
+		fields = append(fields, "remark")
+	}
+	return fields
+}
+
+func sortChanges(changes []models.TunnelChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+}
+
+// Digest returns a hash of the changes in plan, so a previewed plan can be compared
+// with the one computed when it is applied.
+func Digest(plan models.TunnelImportPlan) string {
+	payload, _ := json.Marshal([][]models.TunnelChange{plan.Deletes, plan.Updates, plan.Creates})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
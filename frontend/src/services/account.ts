@@ -0,0 +1,78 @@
+import { parseError } from "./errors";
+
+type AccountServiceBinding = {
+  ListAccounts: () => Promise<any>;
+  GetActiveAccount: () => Promise<any>;
+  SwitchAccount: (userID: number) => Promise<any>;
+  RenameAccount: (userID: number, alias: string) => Promise<any>;
+  RemoveAccount: (userID: number) => Promise<void>;
+};
+
+function getAccountServiceBinding(): AccountServiceBinding {
+  const svc = (window as any).go?.services?.AccountService;
+  if (!svc) {
+    throw new Error("AccountService 未绑定，请重启应用。");
+  }
+  return svc as AccountServiceBinding;
+}
+
+export interface Account {
+  id: number;
+  username: string;
+  email: string;
+  avatar?: string;
+  alias?: string;
+  added_at: string;
+  last_used_at?: string;
+  signed_in: boolean;
+  active: boolean;
+}
+
+export function accountDisplayName(account: Account): string {
+  return account.alias || account.username;
+}
+
+export async function listAccounts(): Promise<Account[]> {
+  try {
+    const svc = getAccountServiceBinding();
+    return ((await svc.ListAccounts()) as Account[] | null) ?? [];
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function getActiveAccount(): Promise<Account | null> {
+  try {
+    const svc = getAccountServiceBinding();
+    return ((await svc.GetActiveAccount()) as Account | null) ?? null;
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function switchAccount(userID: number): Promise<Account> {
+  try {
+    const svc = getAccountServiceBinding();
+    return (await svc.SwitchAccount(userID)) as Account;
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function renameAccount(userID: number, alias: string): Promise<Account> {
+  try {
+    const svc = getAccountServiceBinding();
+    return (await svc.RenameAccount(userID, alias)) as Account;
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function removeAccount(userID: number): Promise<void> {
+  try {
+    const svc = getAccountServiceBinding();
+    await svc.RemoveAccount(userID);
+  } catch (error) {
+    throw parseError(error);
+  }
+}
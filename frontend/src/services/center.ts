@@ -1,11 +1,20 @@
+import { parseError } from "./errors";
+
 type CenterServiceBinding = {
-  GetDashboard: () => Promise<any>;
+  GetDashboard: (requestID: string) => Promise<any>;
   GetRunnerRuntimeStatus: () => Promise<any>;
-  GetTunnelsOverview: (page: number, limit: number, days: number) => Promise<any>;
-  GetRunnerData: (tunnelID: number) => Promise<any>;
-  StartRunner: (tunnelName: string) => Promise<any>;
+  GetTunnelsOverview: (requestID: string, query: TunnelQuery) => Promise<any>;
+  GetRunnerData: (requestID: string, tunnelID: number) => Promise<any>;
+  StartRunner: (requestID: string, tunnelName: string) => Promise<any>;
   StopRunner: () => Promise<any>;
-  GetTrafficDaily: (days: number) => Promise<any>;
+  GetTrafficDaily: (requestID: string, days: number) => Promise<any>;
+  VerifyTunnelDomain: (requestID: string, tunnelName: string) => Promise<any>;
+  VerifyTunnelDomains: (requestID: string) => Promise<any>;
+  ExportTunnels: (requestID: string, options: TunnelExportOptions) => Promise<any>;
+  PreviewTunnelImport: (requestID: string, options: TunnelImportOptions) => Promise<any>;
+  ApplyTunnelImport: (requestID: string, options: TunnelImportOptions) => Promise<any>;
+  GenerateFrpcConfig: (requestID: string, options: FrpcConfigOptions) => Promise<any>;
+  CancelRequest: (requestID: string) => Promise<boolean>;
 };
 
 function getCenterServiceBinding(): CenterServiceBinding {
@@ -16,20 +25,32 @@ function getCenterServiceBinding(): CenterServiceBinding {
   return svc as CenterServiceBinding;
 }
 
-function parseError(error: unknown): Error {
-  if (error instanceof Error) {
-    return error;
-  }
-  if (typeof error === "string") {
-    return new Error(error);
-  }
-  if (typeof error === "object" && error !== null && "message" in error) {
-    const message = (error as { message?: unknown }).message;
-    if (typeof message === "string") {
-      return new Error(message);
-    }
+let requestSeq = 0;
+
+function nextRequestID(): string {
+  requestSeq += 1;
+  return `req-${Date.now().toString(36)}-${requestSeq}`;
+}
+
+// callWithRequest tags a backend call with a request ID so that aborting the
+// signal cancels the in-flight call on the Go side.
+async function callWithRequest<T>(
+  signal: AbortSignal | undefined,
+  call: (svc: CenterServiceBinding, requestID: string) => Promise<unknown>,
+): Promise<T> {
+  const svc = getCenterServiceBinding();
+  const requestID = nextRequestID();
+  const onAbort = () => {
+    void svc.CancelRequest(requestID);
+  };
+  signal?.addEventListener("abort", onAbort, { once: true });
+  try {
+    return (await call(svc, requestID)) as T;
+  } catch (error) {
+    throw parseError(error);
+  } finally {
+    signal?.removeEventListener("abort", onAbort);
   }
-  return new Error("请求失败");
 }
 
 export interface DashboardData {
@@ -64,6 +85,7 @@ export interface DashboardData {
     tunnel_count: number;
     total_traffic_used: number;
   };
+  errors?: Partial<Record<"user" | "traffic" | "tunnels" | "app" | "home", string>>;
 }
 
 export interface TunnelOverviewItem {
@@ -78,6 +100,7 @@ export interface TunnelOverviewItem {
   remote_port: number;
   status: string;
   type: string;
+  created_at?: string;
   total_in?: number;
   total_out?: number;
   total_traffic?: number;
@@ -96,12 +119,28 @@ export interface DailyTrafficResponse {
   }>;
 }
 
+export type TunnelSortKey = "traffic" | "name" | "created";
+
+export interface TunnelQuery {
+  search?: string;
+  types?: string[];
+  statuses?: string[];
+  node_ids?: number[];
+  sort_by?: TunnelSortKey;
+  sort_desc?: boolean;
+  page?: number;
+  limit?: number;
+  all?: boolean;
+  days?: number;
+}
+
 export interface TunnelsOverviewData {
   list: TunnelOverviewItem[];
   page: number;
   limit: number;
   total: number;
   total_page: number;
+  traffic_unavailable?: boolean;
 }
 
 export interface RunnerData {
@@ -123,40 +162,69 @@ export interface RunnerRuntimeStatus {
   started_at?: string;
   tunnel_name?: string;
   node_address?: string;
+  node_id?: number;
+  account_id?: number;
+  account_name?: string;
   command?: string;
   last_error?: string;
   log_lines?: string[];
 }
 
-export async function getDashboard(): Promise<DashboardData> {
-  try {
-    const svc = getCenterServiceBinding();
-    return (await svc.GetDashboard()) as DashboardData;
-  } catch (error) {
-    throw parseError(error);
-  }
+export type DomainCheckStatus = "ok" | "mismatch" | "error" | "skipped";
+
+export interface DomainCheckIssue {
+  code: string;
+  message: string;
+}
+
+export interface DomainCheckResult {
+  tunnel_name: string;
+  tunnel_type: string;
+  domain: string;
+  node_id: number;
+  node_name: string;
+  expected_host?: string;
+  expected_ips: string[];
+  cname?: string;
+  ipv4: string[];
+  ipv6: string[];
+  status: DomainCheckStatus;
+  issues: DomainCheckIssue[];
+  checked_at: string;
+}
+
+export async function getDashboard(signal?: AbortSignal): Promise<DashboardData> {
+  return callWithRequest<DashboardData>(signal, (svc, requestID) =>
+    svc.GetDashboard(requestID),
+  );
 }
 
 export async function getTunnelsOverview(
-  page = 1,
-  limit = 50,
-  days = 2,
+  query: TunnelQuery = {},
+  signal?: AbortSignal,
 ): Promise<TunnelsOverviewData> {
-  try {
-    const svc = getCenterServiceBinding();
-    return (await svc.GetTunnelsOverview(page, limit, days)) as TunnelsOverviewData;
-  } catch (error) {
-    throw parseError(error);
-  }
+  return callWithRequest<TunnelsOverviewData>(signal, (svc, requestID) =>
+    svc.GetTunnelsOverview(requestID, { page: 1, limit: 50, days: 2, ...query }),
+  );
 }
 
-export async function getRunnerData(tunnelID = 0): Promise<RunnerData> {
-  try {
-    const svc = getCenterServiceBinding();
-    return (await svc.GetRunnerData(tunnelID)) as RunnerData;
-  } catch (error) {
-    throw parseError(error);
-  }
+// getAllTunnelsOverview returns every matching tunnel in a single overview query, for
+// pickers that must offer all of them.
+export async function getAllTunnelsOverview(
+  query: TunnelQuery = {},
+  signal?: AbortSignal,
+): Promise<TunnelOverviewItem[]> {
+  const data = await getTunnelsOverview({ ...query, all: true }, signal);
+  return data.list ?? [];
+}
+
+export async function getRunnerData(
+  tunnelID = 0,
+  signal?: AbortSignal,
+): Promise<RunnerData> {
+  return callWithRequest<RunnerData>(signal, (svc, requestID) =>
+    svc.GetRunnerData(requestID, tunnelID),
+  );
 }
 
 export async function getRunnerRuntimeStatus(): Promise<RunnerRuntimeStatus> {
@@ -168,13 +236,13 @@ export async function getRunnerRuntimeStatus(): Promise<RunnerRuntimeStatus> {
   }
 }
 
-export async function startRunner(tunnelName = ""): Promise<RunnerRuntimeStatus> {
-  try {
-    const svc = getCenterServiceBinding();
-    return (await svc.StartRunner(tunnelName)) as RunnerRuntimeStatus;
-  } catch (error) {
-    throw parseError(error);
-  }
+export async function startRunner(
+  tunnelName = "",
+  signal?: AbortSignal,
+): Promise<RunnerRuntimeStatus> {
+  return callWithRequest<RunnerRuntimeStatus>(signal, (svc, requestID) =>
+    svc.StartRunner(requestID, tunnelName),
+  );
 }
 
 export async function stopRunner(): Promise<RunnerRuntimeStatus> {
@@ -186,11 +254,147 @@ export async function stopRunner(): Promise<RunnerRuntimeStatus> {
   }
 }
 
-export async function getTrafficDaily(days = 7): Promise<DailyTrafficResponse> {
-  try {
-    const svc = getCenterServiceBinding();
-    return (await svc.GetTrafficDaily(days)) as DailyTrafficResponse;
-  } catch (error) {
-    throw parseError(error);
-  }
+export async function getTrafficDaily(
+  days = 7,
+  signal?: AbortSignal,
+): Promise<DailyTrafficResponse> {
+  return callWithRequest<DailyTrafficResponse>(signal, (svc, requestID) =>
+    svc.GetTrafficDaily(requestID, days),
+  );
+}
+
+export async function verifyTunnelDomain(
+  tunnelName: string,
+  signal?: AbortSignal,
+): Promise<DomainCheckResult[]> {
+  return callWithRequest<DomainCheckResult[]>(signal, (svc, requestID) =>
+    svc.VerifyTunnelDomain(requestID, tunnelName),
+  );
+}
+
+export async function verifyTunnelDomains(signal?: AbortSignal): Promise<DomainCheckResult[]> {
+  return callWithRequest<DomainCheckResult[]>(signal, (svc, requestID) =>
+    svc.VerifyTunnelDomains(requestID),
+  );
+}
+
+export type TunnelFileFormat = "yaml" | "toml";
+
+export interface TunnelDefinition {
+  name: string;
+  type: string;
+  node_id: number;
+  node_name?: string;
+  local_ip: string;
+  local_port: number;
+  remote_port?: number;
+  custom_domain?: string;
+  remark?: string;
+}
+
+export interface TunnelExportOptions {
+  format?: TunnelFileFormat;
+  path?: string;
+}
+
+export interface TunnelExportResult {
+  path: string;
+  format: TunnelFileFormat;
+  tunnel_count: number;
+  cancelled: boolean;
+}
+
+export interface TunnelImportOptions {
+  path?: string;
+  format?: TunnelFileFormat;
+  prune?: boolean;
+  plan_digest?: string;
+}
+
+export interface TunnelChange {
+  action: "create" | "update" | "delete";
+  name: string;
+  before?: TunnelDefinition;
+  after?: TunnelDefinition;
+  fields?: string[];
+}
+
+export interface TunnelImportPlan {
+  path: string;
+  format: TunnelFileFormat;
+  creates: TunnelChange[];
+  updates: TunnelChange[];
+  deletes: TunnelChange[];
+  unchanged: string[];
+  untracked: string[];
+  digest: string;
+  cancelled: boolean;
+}
+
+export interface TunnelImportResult {
+  plan: TunnelImportPlan;
+  results: Array<TunnelChange & { success: boolean; error?: string }>;
+  applied: number;
+  failed: number;
+}
+
+export async function exportTunnels(
+  options: TunnelExportOptions = {},
+  signal?: AbortSignal,
+): Promise<TunnelExportResult> {
+  return callWithRequest<TunnelExportResult>(signal, (svc, requestID) =>
+    svc.ExportTunnels(requestID, options),
+  );
+}
+
+export async function previewTunnelImport(
+  options: TunnelImportOptions = {},
+  signal?: AbortSignal,
+): Promise<TunnelImportPlan> {
+  return callWithRequest<TunnelImportPlan>(signal, (svc, requestID) =>
+    svc.PreviewTunnelImport(requestID, options),
+  );
+}
+
+export async function applyTunnelImport(
+  options: TunnelImportOptions,
+  signal?: AbortSignal,
+): Promise<TunnelImportResult> {
+  return callWithRequest<TunnelImportResult>(signal, (svc, requestID) =>
+    svc.ApplyTunnelImport(requestID, options),
+  );
+}
+
+export interface FrpcConfigOptions {
+  tunnel_names: string[];
+  group?: boolean;
+  verify?: boolean;
+  output_dir?: string;
+}
+
+export interface GeneratedFrpcConfig {
+  file_name: string;
+  path?: string;
+  node_id: number;
+  node_name: string;
+  server_addr: string;
+  server_port: number;
+  tunnels: string[];
+  content: string;
+  verify_status: "ok" | "failed" | "skipped";
+  verify_output?: string;
+}
+
+export interface FrpcConfigBundle {
+  configs: GeneratedFrpcConfig[];
+  verify_note?: string;
+}
+
+export async function generateFrpcConfig(
+  options: FrpcConfigOptions,
+  signal?: AbortSignal,
+): Promise<FrpcConfigBundle> {
+  return callWithRequest<FrpcConfigBundle>(signal, (svc, requestID) =>
+    svc.GenerateFrpcConfig(requestID, options),
+  );
 }
@@ -0,0 +1,73 @@
+import { parseError } from "./errors";
+
+type DebugServiceBinding = {
+  GetHTTPTraceStatus: () => Promise<any>;
+  GetHTTPTrace: (limit: number, source: string) => Promise<any>;
+  ClearHTTPTrace: () => Promise<void>;
+};
+
+function getDebugServiceBinding(): DebugServiceBinding {
+  const svc = (window as any).go?.services?.DebugService;
+  if (!svc) {
+    throw new Error("DebugService 未绑定，请重启应用。");
+  }
+  return svc as DebugServiceBinding;
+}
+
+export type HTTPTraceSource = "" | "center" | "github" | "oauth";
+
+export interface HTTPTraceEntry {
+  id: number;
+  source: string;
+  method: string;
+  url: string;
+  path: string;
+  status_code: number;
+  error?: string;
+  started_at: string;
+  duration_ms: number;
+  request_headers?: Record<string, string>;
+  request_body?: string;
+  request_size: number;
+  request_truncated?: boolean;
+  response_headers?: Record<string, string>;
+  response_body?: string;
+  response_size: number;
+  response_truncated?: boolean;
+}
+
+export interface HTTPTraceStatus {
+  enabled: boolean;
+  file_path: string;
+  entries: number;
+}
+
+export async function getHTTPTraceStatus(): Promise<HTTPTraceStatus> {
+  try {
+    const svc = getDebugServiceBinding();
+    return (await svc.GetHTTPTraceStatus()) as HTTPTraceStatus;
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function getHTTPTrace(
+  limit = 100,
+  source: HTTPTraceSource = "",
+): Promise<HTTPTraceEntry[]> {
+  try {
+    const svc = getDebugServiceBinding();
+    return ((await svc.GetHTTPTrace(limit, source)) ?? []) as HTTPTraceEntry[];
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function clearHTTPTrace(): Promise<void> {
+  try {
+    const svc = getDebugServiceBinding();
+    await svc.ClearHTTPTrace();
+  } catch (error) {
+    throw parseError(error);
+  }
+}
@@ -0,0 +1,101 @@
+export type AppErrorCode =
+  | "unknown"
+  | "unauthorized"
+  | "forbidden"
+  | "not_found"
+  | "invalid_argument"
+  | "network"
+  | "timeout"
+  | "cancelled"
+  | "rate_limited"
+  | "server_error"
+  | "api_error"
+  | "not_installed"
+  | "runner_busy"
+  | "install_in_progress"
+  | "install_cancelled"
+  | "install_timeout"
+  | "checksum_mismatch"
+  | "unsupported_platform"
+  | "no_tunnel"
+  | "invalid_tunnel"
+  | "storage"
+  | "account_not_found"
+  | "account_signed_out"
+  | "certificate"
+  | "port_in_use"
+  | "plan_changed"
+  | "runner_start_failed"
+  | "install_failed"
+  | "no_node"
+  | "dialog_failed";
+
+export interface CertificateInfo {
+  subject: string;
+  issuer: string;
+  serial_number: string;
+  not_before: string;
+  not_after: string;
+  dns_names?: string[];
+  is_ca: boolean;
+  sha256: string;
+  spki_pin: string;
+}
+
+export class AppError extends Error {
+  code: AppErrorCode;
+  detail?: string;
+  params?: Record<string, string>;
+  messages?: Record<string, string>;
+  certificates?: CertificateInfo[];
+
+  constructor(message: string, code: AppErrorCode = "unknown") {
+    super(message);
+    this.name = "AppError";
+    this.code = code;
+  }
+}
+
+export function parseError(error: unknown): AppError {
+  if (error instanceof AppError) {
+    return error;
+  }
+  if (error instanceof Error) {
+    return new AppError(error.message);
+  }
+  if (typeof error === "string") {
+    return new AppError(error);
+  }
+  if (typeof error === "object" && error !== null && "message" in error) {
+    const payload = error as {
+      code?: unknown;
+      message?: unknown;
+      detail?: unknown;
+      params?: unknown;
+      messages?: unknown;
+      certificates?: unknown;
+    };
+    if (typeof payload.message === "string") {
+      const code = typeof payload.code === "string" ? (payload.code as AppErrorCode) : "unknown";
+      const parsed = new AppError(payload.message, code);
+      if (typeof payload.detail === "string") {
+        parsed.detail = payload.detail;
+      }
+      if (typeof payload.params === "object" && payload.params !== null) {
+        parsed.params = payload.params as Record<string, string>;
+      }
+      if (typeof payload.messages === "object" && payload.messages !== null) {
+        parsed.messages = payload.messages as Record<string, string>;
+      }
+      if (Array.isArray(payload.certificates)) {
+        parsed.certificates = payload.certificates as CertificateInfo[];
+      }
+      return parsed;
+    }
+  }
+  return new AppError("请求失败");
+}
+
+export function hasErrorCode(error: unknown, code: AppErrorCode): boolean {
+  return error instanceof AppError && error.code === code;
+}
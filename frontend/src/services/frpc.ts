@@ -1,3 +1,5 @@
+import { parseError } from "./errors";
+
 type FrpcServiceBinding = {
   GetFrpcStatus: () => Promise<any>;
   GetGitHubMirrorURL: () => Promise<string>;
@@ -5,6 +7,9 @@ type FrpcServiceBinding = {
   CancelInstallOrUpdateFrpc: () => Promise<void>;
   RemoveFrpc: () => Promise<void>;
   SetGitHubMirrorURL: (url: string) => Promise<void>;
+  HasGitHubToken: () => Promise<boolean>;
+  SetGitHubToken: (token: string) => Promise<any>;
+  ClearGitHubToken: () => Promise<void>;
 };
 
 function getFrpcServiceBinding(): FrpcServiceBinding {
@@ -15,22 +20,6 @@ function getFrpcServiceBinding(): FrpcServiceBinding {
   return svc as FrpcServiceBinding;
 }
 
-function parseError(error: unknown): Error {
-  if (error instanceof Error) {
-    return error;
-  }
-  if (typeof error === "string") {
-    return new Error(error);
-  }
-  if (typeof error === "object" && error !== null && "message" in error) {
-    const message = (error as { message?: unknown }).message;
-    if (typeof message === "string") {
-      return new Error(message);
-    }
-  }
-  return new Error("请求失败");
-}
-
 export interface FrpcPaths {
   userdata_dir: string;
   frpc_dir: string;
@@ -70,6 +59,16 @@ export interface FrpcReleaseInfo {
   asset: FrpcReleaseAsset;
 }
 
+export interface GitHubRateLimit {
+  limit: number;
+  remaining: number;
+  used: number;
+  resource?: string;
+  reset_at: string;
+  checked_at: string;
+  from_cache: boolean;
+}
+
 export interface FrpcStatus {
   goos: string;
   goarch: string;
@@ -79,6 +78,8 @@ export interface FrpcStatus {
   latest?: FrpcReleaseInfo;
   update_available: boolean;
   latest_error?: string;
+  github_token_set: boolean;
+  github_rate_limit?: GitHubRateLimit;
 }
 
 export interface FrpcInstallResult {
@@ -139,3 +140,30 @@ export async function setGitHubMirrorURL(url: string): Promise<void> {
     throw parseError(error);
   }
 }
+
+export async function hasGitHubToken(): Promise<boolean> {
+  try {
+    const svc = getFrpcServiceBinding();
+    return await svc.HasGitHubToken();
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function setGitHubToken(token: string): Promise<GitHubRateLimit | null> {
+  try {
+    const svc = getFrpcServiceBinding();
+    return ((await svc.SetGitHubToken(token)) ?? null) as GitHubRateLimit | null;
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function clearGitHubToken(): Promise<void> {
+  try {
+    const svc = getFrpcServiceBinding();
+    await svc.ClearGitHubToken();
+  } catch (error) {
+    throw parseError(error);
+  }
+}
@@ -0,0 +1,96 @@
+import { parseError, type CertificateInfo } from "./errors";
+
+type NetworkServiceBinding = {
+  GetProxyConfig: () => Promise<any>;
+  SetProxyConfig: (proxy: ProxyConfig) => Promise<void>;
+  TestProxyConnection: (proxy: ProxyConfig) => Promise<any>;
+  GetTLSConfig: () => Promise<any>;
+  SetTLSConfig: (tls: TLSConfig) => Promise<void>;
+};
+
+function getNetworkServiceBinding(): NetworkServiceBinding {
+  const svc = (window as any).go?.services?.NetworkService;
+  if (!svc) {
+    throw new Error("NetworkService 未绑定，请重启应用。");
+  }
+  return svc as NetworkServiceBinding;
+}
+
+export type ProxyMode = "none" | "system" | "manual";
+
+export interface ProxyConfig {
+  mode: ProxyMode;
+  url: string;
+  noProxy: string;
+  applyToFrpc: boolean;
+}
+
+export interface ProxyTestTarget {
+  name: string;
+  url: string;
+  proxy_url?: string;
+  reachable: boolean;
+  status_code?: number;
+  latency_ms: number;
+  error?: string;
+  certificates?: CertificateInfo[];
+}
+
+export interface TLSConfig {
+  caFiles: string[];
+  centerPins: string[];
+}
+
+export interface ProxyTestResult {
+  mode: ProxyMode;
+  success: boolean;
+  targets: ProxyTestTarget[];
+}
+
+export async function getProxyConfig(): Promise<ProxyConfig> {
+  try {
+    const svc = getNetworkServiceBinding();
+    return (await svc.GetProxyConfig()) as ProxyConfig;
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function setProxyConfig(proxy: ProxyConfig): Promise<void> {
+  try {
+    const svc = getNetworkServiceBinding();
+    await svc.SetProxyConfig(proxy);
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function testProxyConnection(
+  proxy: ProxyConfig,
+): Promise<ProxyTestResult> {
+  try {
+    const svc = getNetworkServiceBinding();
+    return (await svc.TestProxyConnection(proxy)) as ProxyTestResult;
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function getTLSConfig(): Promise<TLSConfig> {
+  try {
+    const svc = getNetworkServiceBinding();
+    const tls = (await svc.GetTLSConfig()) as Partial<TLSConfig>;
+    return { caFiles: tls.caFiles ?? [], centerPins: tls.centerPins ?? [] };
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function setTLSConfig(tls: TLSConfig): Promise<void> {
+  try {
+    const svc = getNetworkServiceBinding();
+    await svc.SetTLSConfig(tls);
+  } catch (error) {
+    throw parseError(error);
+  }
+}
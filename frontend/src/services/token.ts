@@ -0,0 +1,68 @@
+import { parseError } from "./errors";
+
+type TokenServiceBinding = {
+  BeginDeviceLogin: () => Promise<any>;
+  GetDeviceLoginStatus: () => Promise<any>;
+  CancelDeviceLogin: () => Promise<boolean>;
+};
+
+function getTokenServiceBinding(): TokenServiceBinding {
+  const svc = (window as any).go?.services?.TokenService;
+  if (!svc) {
+    throw new Error("TokenService 未绑定，请重启应用。");
+  }
+  return svc as TokenServiceBinding;
+}
+
+export const DEVICE_LOGIN_EVENT = "oauth_device_login";
+export const OAUTH_PORT_FALLBACK_EVENT = "oauth_port_fallback";
+
+export interface OAuthPortFallback {
+  busy_ports: number[];
+  owners?: string;
+  port: number;
+}
+
+export type DeviceLoginState =
+  | "pending"
+  | "succeeded"
+  | "failed"
+  | "expired"
+  | "cancelled";
+
+export interface DeviceLoginStatus {
+  state: DeviceLoginState;
+  user_code: string;
+  verification_uri: string;
+  verification_uri_complete?: string;
+  expires_at?: string;
+  interval: number;
+  error?: string;
+}
+
+export async function beginDeviceLogin(): Promise<DeviceLoginStatus> {
+  try {
+    const svc = getTokenServiceBinding();
+    return (await svc.BeginDeviceLogin()) as DeviceLoginStatus;
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function getDeviceLoginStatus(): Promise<DeviceLoginStatus | null> {
+  try {
+    const svc = getTokenServiceBinding();
+    return ((await svc.GetDeviceLoginStatus()) as DeviceLoginStatus | null) ?? null;
+  } catch (error) {
+    throw parseError(error);
+  }
+}
+
+export async function cancelDeviceLogin(): Promise<boolean> {
+  try {
+    const svc = getTokenServiceBinding();
+    return await svc.CancelDeviceLogin();
+  } catch (error) {
+    throw parseError(error);
+  }
+}
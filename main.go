@@ -3,9 +3,11 @@ package main
 import (
 	"context"
 	"embed"
+	"os"
 
 	"loliashizuku/backend"
-	"loliashizuku/backend/config"
+	"loliashizuku/backend/apperror"
+	"loliashizuku/backend/demo"
 	"loliashizuku/backend/services"
 
 	"github.com/wailsapp/wails/v2"
@@ -22,8 +24,25 @@ const (
 )
 
 func main() {
+	if demo.IsFrpcStub(os.Args) {
+		os.Exit(demo.RunFrpcStub(os.Args[1:]))
+	}
+
+	if services.DemoModeRequested(os.Args[1:]) {
+		demoServer, err := demo.Start()
+		if err != nil {
+			println("Failed to start demo server:", err.Error())
+			os.Exit(1)
+		}
+		defer demoServer.Close()
+		if err := services.EnableDemoMode(demoServer); err != nil {
+			println("Failed to enable demo mode:", err.Error())
+			os.Exit(1)
+		}
+	}
+
 	// Initialize configuration early to restore window size
-	configManager := config.NewManager()
+	configManager := services.NewConfigManager()
 	if err := configManager.Initialize(); err != nil {
 		println("Failed to initialize config:", err.Error())
 	}
@@ -41,6 +60,13 @@ func main() {
 	tokenService := services.NewTokenService()
 	centerService := services.NewCenterService()
 	frpcService := services.NewFrpcService()
+	nodeProbeService := services.NewNodeProbeService(centerService)
+	checkInService := services.NewCheckInService(centerService, configManager)
+	trafficHistoryService := services.NewTrafficHistoryService(centerService)
+	accountService := services.NewAccountService(centerService)
+	nodeWatcherService := services.NewNodeWatcherService(centerService, configManager)
+	debugService := services.NewDebugService(configManager)
+	networkService := services.NewNetworkService(configManager)
 
 	// Create application with options
 	err := wails.Run(&options.App{
@@ -54,12 +80,22 @@ func main() {
 		AssetServer: &assetserver.Options{
 			Assets: assets,
 		},
-		OnStartup: app.Startup,
+		ErrorFormatter: apperror.Format,
+		OnStartup: func(ctx context.Context) {
+			app.Startup(ctx)
+			tokenService.Startup(ctx)
+			centerService.Startup(ctx)
+			checkInService.Start(ctx)
+			trafficHistoryService.Start(ctx)
+			nodeWatcherService.Start(ctx)
+		},
 		OnBeforeClose: func(ctx context.Context) bool {
 			_, _ = centerService.StopRunner()
 			return false
 		},
 		OnShutdown: func(ctx context.Context) {
+			centerService.Shutdown()
+			trafficHistoryService.Shutdown()
 			_, _ = centerService.StopRunner()
 		},
 		Bind: []interface{}{
@@ -68,6 +104,13 @@ func main() {
 			tokenService,
 			centerService,
 			frpcService,
+			nodeProbeService,
+			checkInService,
+			trafficHistoryService,
+			accountService,
+			nodeWatcherService,
+			debugService,
+			networkService,
 		},
 	})
 